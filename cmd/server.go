@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	database "socialapp/db"
@@ -10,7 +12,9 @@ import (
 	"socialapp/internal/repository"
 	"socialapp/internal/service"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -19,7 +23,8 @@ import (
 )
 
 var (
-	APP_PORT = "8080"
+	APP_PORT           = "8080"
+	LOCAL_STORAGE_PATH = "/uploads"
 )
 
 func Server() error {
@@ -39,22 +44,133 @@ func Server() error {
 
 	// repository init
 	userRepo := repository.NewUserRepository(logger, db)
-	s3Repo := repository.NewS3Repository(logger)
-	postRepo := repository.NewPostRepository(logger, db)
+	blobCfg := blobConfig()
+	blobRepo, err := repository.NewBlobRepository(logger, blobCfg)
+	if err != nil {
+		logger.Info().Msg(fmt.Sprintf("Storage init error: %s", err.Error()))
+		return err
+	}
+	searchLanguage := os.Getenv("SEARCH_LANGUAGE")
+	if searchLanguage == "" {
+		searchLanguage = "english"
+	}
+	postRepo := repository.NewPostRepository(logger, db, searchLanguage)
+	reindexed, _, err := postRepo.ReindexSearch(context.Background())
+	if err != nil {
+		logger.Info().Msg(fmt.Sprintf("Search reindex error: %s", err.Error()))
+		return err
+	}
+	if reindexed > 0 {
+		logger.Info().Int64("posts", reindexed).Str("language", searchLanguage).Msg("posts reindexed for search")
+	}
 	friendshipRepo := repository.NewFriendshipRepository(logger, db)
+	sessionRepo := repository.NewSessionRepository(logger, db)
+	notificationRepo := repository.NewNotificationRepository(logger, db)
+	eventRepo := repository.NewEventRepository(logger)
+	conversationRepo := repository.NewConversationRepository(logger, db)
+	tagRepo := repository.NewTagRepository(logger, db)
+	blockRepo := repository.NewBlockRepository(logger, db)
+	resetRepo := repository.NewPasswordResetRepository(logger, db)
+	verificationRepo := repository.NewCredentialVerificationRepository(logger, db)
+	twoFactorRepo := repository.NewTwoFactorRepository(logger, db)
+	loginAttemptDriver := os.Getenv("LOGIN_ATTEMPT_DRIVER")
+	if loginAttemptDriver == "" {
+		loginAttemptDriver = repository.LoginAttemptDriverMemory
+	}
+	loginAttemptRepo, err := repository.NewLoginAttemptRepository(logger, loginAttemptDriver, db)
+	if err != nil {
+		logger.Info().Msg(fmt.Sprintf("Login attempt store init error: %s", err.Error()))
+		return err
+	}
+	securityEventRepo := repository.NewSecurityEventRepository(logger, db)
+	messageSender, err := repository.NewMessageSender(logger, messageConfig())
+	if err != nil {
+		logger.Info().Msg(fmt.Sprintf("Message sender init error: %s", err.Error()))
+		return err
+	}
 
 	salt, err := strconv.Atoi(os.Getenv("BCRYPT_SALT"))
 	if err != nil {
 		salt = 8
 	}
+	accessTokenTTL, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL"))
+	if err != nil {
+		accessTokenTTL = 15 * time.Minute
+	}
+	refreshTokenTTL, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL"))
+	if err != nil {
+		refreshTokenTTL = 30 * 24 * time.Hour
+	}
+	maxImageDimension, err := strconv.Atoi(os.Getenv("IMAGE_MAX_DIMENSION"))
+	if err != nil {
+		maxImageDimension = 4096
+	}
+	imageWorkers, err := strconv.Atoi(os.Getenv("IMAGE_WORKERS"))
+	if err != nil {
+		imageWorkers = 0
+	}
+	notificationRetention, err := time.ParseDuration(os.Getenv("NOTIFICATION_RETENTION"))
+	if err != nil {
+		notificationRetention = 30 * 24 * time.Hour
+	}
+	passwordResetTTL, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_TTL"))
+	if err != nil {
+		passwordResetTTL = 30 * time.Minute
+	}
+	verificationCodeTTL, err := time.ParseDuration(os.Getenv("VERIFICATION_CODE_TTL"))
+	if err != nil {
+		verificationCodeTTL = 15 * time.Minute
+	}
+	twoFactorIssuer := os.Getenv("TOTP_ISSUER")
+	if twoFactorIssuer == "" {
+		twoFactorIssuer = "socialapp"
+	}
+	loginFailureWindow, err := time.ParseDuration(os.Getenv("LOGIN_FAILURE_WINDOW"))
+	if err != nil {
+		loginFailureWindow = 15 * time.Minute
+	}
+	loginLockout, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT"))
+	if err != nil {
+		loginLockout = 15 * time.Minute
+	}
+	auditRetention, err := time.ParseDuration(os.Getenv("SECURITY_EVENT_RETENTION"))
+	if err != nil {
+		auditRetention = 90 * 24 * time.Hour
+	}
 	// service registry
 	service := service.New(
-		service.Config{Salt: salt, JwtSecret: os.Getenv("JWT_SECRET")},
+		service.Config{
+			Salt:                  salt,
+			JwtSecret:             os.Getenv("JWT_SECRET"),
+			AccessTokenTTL:        accessTokenTTL,
+			RefreshTokenTTL:       refreshTokenTTL,
+			MaxImageDimension:     maxImageDimension,
+			ImageWorkers:          imageWorkers,
+			NotificationRetention: notificationRetention,
+			PasswordResetTTL:      passwordResetTTL,
+			VerificationCodeTTL:   verificationCodeTTL,
+			TwoFactorIssuer:       twoFactorIssuer,
+			LoginFailureWindow:    loginFailureWindow,
+			LoginLockout:          loginLockout,
+			AuditRetention:        auditRetention,
+		},
 		logger,
 		userRepo,
-		s3Repo,
+		blobRepo,
 		postRepo,
 		friendshipRepo,
+		sessionRepo,
+		notificationRepo,
+		eventRepo,
+		conversationRepo,
+		tagRepo,
+		blockRepo,
+		resetRepo,
+		messageSender,
+		verificationRepo,
+		twoFactorRepo,
+		loginAttemptRepo,
+		securityEventRepo,
 	)
 
 	// middleware init
@@ -65,6 +181,12 @@ func Server() error {
 
 	// echo server
 	e := echo.New()
+	extractor, err := ipExtractor()
+	if err != nil {
+		logger.Info().Msg(fmt.Sprintf("Trusted proxies error: %s", err.Error()))
+		return err
+	}
+	e.IPExtractor = extractor
 	e.Pre(middleware.RemoveTrailingSlash())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
@@ -83,6 +205,9 @@ func Server() error {
 
 	// add restapi route
 	rest.MakeRoute(e)
+	if blobCfg.Driver == repository.BlobDriverLocal {
+		e.Static(LOCAL_STORAGE_PATH, blobCfg.LocalDir)
+	}
 
 	errs := make(chan error)
 	go func() {
@@ -90,6 +215,39 @@ func Server() error {
 		errs <- e.Start(fmt.Sprintf(":%s", APP_PORT))
 	}()
 
+	// remove expired notifications periodically
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			if _, err := service.CleanupNotifications(context.Background()); err != nil {
+				logger.Error().Err(err).Msg("notification cleanup error")
+			}
+		}
+	}()
+
+	// forget old failed logins periodically
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := service.CleanupLoginAttempts(context.Background()); err != nil {
+				logger.Error().Err(err).Msg("login attempt cleanup error")
+			}
+		}
+	}()
+
+	// remove old security events periodically
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			if _, err := service.CleanupSecurityEvents(context.Background()); err != nil {
+				logger.Error().Err(err).Msg("security event cleanup error")
+			}
+		}
+	}()
+
 	go func() {
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, syscall.SIGINT)
@@ -98,3 +256,67 @@ func Server() error {
 
 	return <-errs
 }
+
+// blobConfig read the storage driver settings, defaulting to the s3 bucket used in production
+func blobConfig() repository.BlobConfig {
+	cfg := repository.BlobConfig{
+		Driver:         os.Getenv("STORAGE_DRIVER"),
+		LocalDir:       os.Getenv("STORAGE_LOCAL_DIR"),
+		LocalPublicURL: os.Getenv("STORAGE_PUBLIC_URL"),
+		S3Endpoint:     os.Getenv("S3_ENDPOINT"),
+		S3Region:       os.Getenv("S3_REGION"),
+		S3Bucket:       os.Getenv("S3_BUCKET_NAME"),
+		S3AccessKey:    os.Getenv("S3_ID"),
+		S3SecretKey:    os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:    os.Getenv("S3_USE_PATH_STYLE") == "true",
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = repository.BlobDriverS3
+	}
+	if cfg.LocalDir == "" {
+		cfg.LocalDir = "./uploads"
+	}
+	if cfg.LocalPublicURL == "" {
+		cfg.LocalPublicURL = fmt.Sprintf("http://localhost:%s%s", APP_PORT, LOCAL_STORAGE_PATH)
+	}
+	if cfg.S3Region == "" {
+		cfg.S3Region = "ap-southeast-1"
+	}
+	return cfg
+}
+
+// messageConfig read the message sender settings. The driver has no default since the log
+// driver writes reset tokens and verification codes to the application log.
+func messageConfig() repository.MessageConfig {
+	cfg := repository.MessageConfig{
+		Driver:   os.Getenv("MESSAGE_DRIVER"),
+		FilePath: os.Getenv("MESSAGE_FILE_PATH"),
+	}
+	if cfg.FilePath == "" {
+		cfg.FilePath = "./messages.log"
+	}
+	return cfg
+}
+
+// ipExtractor return how the client ip is read for login throttling and audit. Without TRUSTED_PROXIES
+// the ip of the connection is used, otherwise X-Forwarded-For is read through the listed proxy ranges only.
+func ipExtractor() (echo.IPExtractor, error) {
+	proxies := os.Getenv("TRUSTED_PROXIES")
+	if proxies == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range strings.Split(proxies, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
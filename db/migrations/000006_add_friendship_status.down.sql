@@ -0,0 +1,5 @@
+DROP INDEX index_friendships_user_status;
+DROP INDEX index_friendships_added_status;
+
+DELETE FROM FRIENDSHIPS WHERE STATUS != 'accepted';
+ALTER TABLE FRIENDSHIPS DROP COLUMN STATUS;
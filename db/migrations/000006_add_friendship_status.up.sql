@@ -0,0 +1,5 @@
+ALTER TABLE FRIENDSHIPS ADD COLUMN STATUS VARCHAR(16) NOT NULL DEFAULT 'accepted';
+ALTER TABLE FRIENDSHIPS ALTER COLUMN STATUS SET DEFAULT 'pending';
+
+CREATE INDEX index_friendships_user_status ON FRIENDSHIPS (USER_ID, STATUS);
+CREATE INDEX index_friendships_added_status ON FRIENDSHIPS (ADDED_BY, STATUS);
@@ -0,0 +1,6 @@
+DROP INDEX index_sessions_refresh_token_hash;
+DROP INDEX index_sessions_user_id;
+
+ALTER TABLE SESSIONS DROP CONSTRAINT fk_sessions_user;
+
+DROP TABLE SESSIONS;
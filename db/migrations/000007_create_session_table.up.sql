@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS SESSIONS (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NOT NULL,
+    REFRESH_TOKEN_HASH VARCHAR(64) NOT NULL,
+    EXPIRES_AT BIGINT NOT NULL,
+    REVOKED_AT BIGINT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    UPDATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_sessions_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX index_sessions_refresh_token_hash ON SESSIONS (REFRESH_TOKEN_HASH);
+CREATE INDEX index_sessions_user_id ON SESSIONS (USER_ID);
@@ -0,0 +1,2 @@
+ALTER TABLE COMMENTS DROP CONSTRAINT fk_comments_post;
+ALTER TABLE COMMENTS ADD CONSTRAINT fk_comments_post FOREIGN KEY(POST_ID) REFERENCES POSTS(id) ON DELETE CASCADE;
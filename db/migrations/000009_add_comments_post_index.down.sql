@@ -0,0 +1 @@
+DROP INDEX index_comments_post_created_at;
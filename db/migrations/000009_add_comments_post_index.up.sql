@@ -0,0 +1 @@
+CREATE INDEX index_comments_post_created_at ON COMMENTS (POST_ID, CREATED_AT DESC, ID DESC);
@@ -0,0 +1 @@
+DROP INDEX index_posts_user_created_at;
@@ -0,0 +1 @@
+CREATE INDEX index_posts_user_created_at ON POSTS (USER_ID, CREATED_AT DESC, ID DESC);
@@ -0,0 +1,5 @@
+DROP INDEX index_posts_content;
+CREATE INDEX index_posts_content ON POSTS USING GIN (CONTENT_HTML gin_trgm_ops);
+
+ALTER TABLE POSTS DROP COLUMN CONTENT_TEXT;
+ALTER TABLE POSTS ALTER COLUMN CONTENT_HTML TYPE VARCHAR(512);
@@ -0,0 +1,7 @@
+ALTER TABLE POSTS ALTER COLUMN CONTENT_HTML TYPE TEXT;
+ALTER TABLE POSTS ADD COLUMN CONTENT_TEXT TEXT NOT NULL DEFAULT '';
+
+UPDATE POSTS SET CONTENT_TEXT = TRIM(REGEXP_REPLACE(REGEXP_REPLACE(CONTENT_HTML, '<[^>]*>', ' ', 'g'), '\s+', ' ', 'g'));
+
+DROP INDEX index_posts_content;
+CREATE INDEX index_posts_content ON POSTS USING GIN (CONTENT_TEXT gin_trgm_ops);
@@ -0,0 +1,8 @@
+DROP INDEX index_notifications_user_created_at;
+DROP INDEX index_notifications_user_unread;
+DROP INDEX index_notifications_created_at;
+
+ALTER TABLE NOTIFICATIONS DROP CONSTRAINT fk_notifications_user;
+ALTER TABLE NOTIFICATIONS DROP CONSTRAINT fk_notifications_actor;
+
+DROP TABLE NOTIFICATIONS;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS NOTIFICATIONS (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NOT NULL,
+    ACTOR_ID INTEGER NOT NULL,
+    TYPE VARCHAR(32) NOT NULL,
+    REFERENCE_ID BIGINT NOT NULL DEFAULT 0,
+    READ_AT BIGINT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_notifications_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notifications_actor FOREIGN KEY(ACTOR_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_notifications_user_created_at ON NOTIFICATIONS (USER_ID, CREATED_AT DESC);
+CREATE INDEX index_notifications_user_unread ON NOTIFICATIONS (USER_ID) WHERE READ_AT IS NULL;
+CREATE INDEX index_notifications_created_at ON NOTIFICATIONS USING BRIN (CREATED_AT);
@@ -0,0 +1,15 @@
+DROP INDEX index_messages_conversation_created_at;
+DROP INDEX index_messages_conversation_unread;
+
+ALTER TABLE MESSAGES DROP CONSTRAINT fk_messages_conversation;
+ALTER TABLE MESSAGES DROP CONSTRAINT fk_messages_sender;
+
+DROP TABLE MESSAGES;
+
+DROP INDEX index_conversations_user1_last_message;
+DROP INDEX index_conversations_user2_last_message;
+
+ALTER TABLE CONVERSATIONS DROP CONSTRAINT fk_conversations_user1;
+ALTER TABLE CONVERSATIONS DROP CONSTRAINT fk_conversations_user2;
+
+DROP TABLE CONVERSATIONS;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS CONVERSATIONS (
+    ID SERIAL PRIMARY KEY,
+    -- participants are stored ordered so a pair has a single conversation
+    USER1_ID INTEGER NOT NULL,
+    USER2_ID INTEGER NOT NULL,
+    LAST_MESSAGE_AT BIGINT NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    UPDATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_conversations_user1 FOREIGN KEY(USER1_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_conversations_user2 FOREIGN KEY(USER2_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT unique_conversations_users UNIQUE (USER1_ID, USER2_ID),
+    CONSTRAINT check_conversations_users CHECK (USER1_ID < USER2_ID)
+);
+
+CREATE INDEX index_conversations_user1_last_message ON CONVERSATIONS (USER1_ID, LAST_MESSAGE_AT DESC);
+CREATE INDEX index_conversations_user2_last_message ON CONVERSATIONS (USER2_ID, LAST_MESSAGE_AT DESC);
+
+CREATE TABLE IF NOT EXISTS MESSAGES (
+    ID SERIAL PRIMARY KEY,
+    CONVERSATION_ID INTEGER NOT NULL,
+    SENDER_ID INTEGER NOT NULL,
+    CONTENT TEXT NOT NULL,
+    READ_AT BIGINT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_messages_conversation FOREIGN KEY(CONVERSATION_ID) REFERENCES CONVERSATIONS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_messages_sender FOREIGN KEY(SENDER_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_messages_conversation_created_at ON MESSAGES (CONVERSATION_ID, CREATED_AT DESC, ID DESC);
+CREATE INDEX index_messages_conversation_unread ON MESSAGES (CONVERSATION_ID, SENDER_ID) WHERE READ_AT IS NULL;
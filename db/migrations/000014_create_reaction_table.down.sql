@@ -0,0 +1,23 @@
+DROP INDEX index_comment_reactions_user;
+
+ALTER TABLE COMMENT_REACTIONS DROP CONSTRAINT fk_comment_reactions_comment;
+ALTER TABLE COMMENT_REACTIONS DROP CONSTRAINT fk_comment_reactions_user;
+
+DROP TABLE COMMENT_REACTIONS;
+
+DROP INDEX index_post_reactions_user;
+
+ALTER TABLE POST_REACTIONS DROP CONSTRAINT fk_post_reactions_post;
+ALTER TABLE POST_REACTIONS DROP CONSTRAINT fk_post_reactions_user;
+
+DROP TABLE POST_REACTIONS;
+
+ALTER TABLE COMMENTS
+    DROP COLUMN LIKE_COUNT,
+    DROP COLUMN LOVE_COUNT,
+    DROP COLUMN LAUGH_COUNT;
+
+ALTER TABLE POSTS
+    DROP COLUMN LIKE_COUNT,
+    DROP COLUMN LOVE_COUNT,
+    DROP COLUMN LAUGH_COUNT;
@@ -0,0 +1,35 @@
+ALTER TABLE POSTS
+    ADD COLUMN LIKE_COUNT INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN LOVE_COUNT INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN LAUGH_COUNT INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE COMMENTS
+    ADD COLUMN LIKE_COUNT INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN LOVE_COUNT INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN LAUGH_COUNT INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS POST_REACTIONS (
+    POST_ID INTEGER NOT NULL,
+    USER_ID INTEGER NOT NULL,
+    TYPE VARCHAR(16) NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    PRIMARY KEY (POST_ID, USER_ID),
+    CONSTRAINT fk_post_reactions_post FOREIGN KEY(POST_ID) REFERENCES POSTS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_reactions_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT check_post_reactions_type CHECK (TYPE IN ('like', 'love', 'laugh'))
+);
+
+CREATE INDEX index_post_reactions_user ON POST_REACTIONS (USER_ID);
+
+CREATE TABLE IF NOT EXISTS COMMENT_REACTIONS (
+    COMMENT_ID INTEGER NOT NULL,
+    USER_ID INTEGER NOT NULL,
+    TYPE VARCHAR(16) NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    PRIMARY KEY (COMMENT_ID, USER_ID),
+    CONSTRAINT fk_comment_reactions_comment FOREIGN KEY(COMMENT_ID) REFERENCES COMMENTS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_reactions_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT check_comment_reactions_type CHECK (TYPE IN ('like', 'love', 'laugh'))
+);
+
+CREATE INDEX index_comment_reactions_user ON COMMENT_REACTIONS (USER_ID);
@@ -0,0 +1,11 @@
+DROP INDEX index_comments_post_created_at;
+CREATE INDEX index_comments_post_created_at ON COMMENTS (POST_ID, CREATED_AT DESC, ID DESC);
+
+DROP INDEX index_comments_parent_created_at;
+
+ALTER TABLE COMMENTS DROP CONSTRAINT fk_comments_parent;
+
+ALTER TABLE COMMENTS
+    DROP COLUMN PARENT_ID,
+    DROP COLUMN DEPTH,
+    DROP COLUMN REPLY_COUNT;
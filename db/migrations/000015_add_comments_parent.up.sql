@@ -0,0 +1,11 @@
+ALTER TABLE COMMENTS
+    ADD COLUMN PARENT_ID INTEGER NULL,
+    ADD COLUMN DEPTH SMALLINT NOT NULL DEFAULT 0,
+    ADD COLUMN REPLY_COUNT INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT fk_comments_parent FOREIGN KEY(PARENT_ID) REFERENCES COMMENTS(id) ON DELETE CASCADE;
+
+CREATE INDEX index_comments_parent_created_at ON COMMENTS (PARENT_ID, CREATED_AT, ID) WHERE PARENT_ID IS NOT NULL;
+
+-- post listings only page through top-level comments
+DROP INDEX index_comments_post_created_at;
+CREATE INDEX index_comments_post_created_at ON COMMENTS (POST_ID, CREATED_AT DESC, ID DESC) WHERE PARENT_ID IS NULL;
@@ -0,0 +1,11 @@
+DROP INDEX index_comment_revisions_comment_created_at;
+DROP INDEX index_comment_revisions_user;
+
+ALTER TABLE COMMENT_REVISIONS DROP CONSTRAINT fk_comment_revisions_comment;
+ALTER TABLE COMMENT_REVISIONS DROP CONSTRAINT fk_comment_revisions_user;
+
+DROP TABLE COMMENT_REVISIONS;
+
+ALTER TABLE COMMENTS
+    DROP COLUMN EDITED_AT,
+    DROP COLUMN DELETED_AT;
@@ -0,0 +1,17 @@
+ALTER TABLE COMMENTS
+    ADD COLUMN EDITED_AT BIGINT NULL,
+    ADD COLUMN DELETED_AT BIGINT NULL;
+
+CREATE TABLE IF NOT EXISTS COMMENT_REVISIONS (
+    ID SERIAL PRIMARY KEY,
+    COMMENT_ID INTEGER NOT NULL,
+    -- CONTENT is the comment text before the edit or deletion
+    CONTENT TEXT NOT NULL,
+    EDITED_BY INTEGER NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_comment_revisions_comment FOREIGN KEY(COMMENT_ID) REFERENCES COMMENTS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_revisions_user FOREIGN KEY(EDITED_BY) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_comment_revisions_comment_created_at ON COMMENT_REVISIONS (COMMENT_ID, CREATED_AT DESC, ID DESC);
+CREATE INDEX index_comment_revisions_user ON COMMENT_REVISIONS (EDITED_BY);
@@ -0,0 +1,19 @@
+ALTER TABLE POSTS ADD COLUMN TAGS VARCHAR(512) NOT NULL DEFAULT '';
+
+UPDATE POSTS P SET TAGS = COALESCE((
+    SELECT STRING_AGG(TG.NAME, ',' ORDER BY PT.SORT_ORDER)
+    FROM POST_TAGS PT
+    JOIN TAGS TG ON PT.TAG_ID = TG.ID
+    WHERE PT.POST_ID = P.ID
+), '');
+
+CREATE INDEX index_posts_tag ON POSTS USING GIN (TAGS gin_trgm_ops);
+
+DROP INDEX index_post_tags_tag_post;
+DROP INDEX index_post_tags_created_at;
+
+ALTER TABLE POST_TAGS DROP CONSTRAINT fk_post_tags_post;
+ALTER TABLE POST_TAGS DROP CONSTRAINT fk_post_tags_tag;
+
+DROP TABLE POST_TAGS;
+DROP TABLE TAGS;
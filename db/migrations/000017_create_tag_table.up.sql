@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS TAGS (
+    ID SERIAL PRIMARY KEY,
+    NAME VARCHAR(64) NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT unique_tags_name UNIQUE (NAME)
+);
+
+CREATE TABLE IF NOT EXISTS POST_TAGS (
+    POST_ID INTEGER NOT NULL,
+    TAG_ID INTEGER NOT NULL,
+    -- SORT_ORDER keeps the order the tags were given in
+    SORT_ORDER SMALLINT NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    PRIMARY KEY (POST_ID, TAG_ID),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY(POST_ID) REFERENCES POSTS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_tags_tag FOREIGN KEY(TAG_ID) REFERENCES TAGS(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_post_tags_tag_post ON POST_TAGS (TAG_ID, POST_ID);
+CREATE INDEX index_post_tags_created_at ON POST_TAGS (CREATED_AT);
+
+-- move the comma joined tags of every post to the new tables, lowercased and trimmed.
+-- Tags longer than a tag name are truncated rather than dropped.
+INSERT INTO TAGS (NAME, CREATED_AT)
+SELECT DISTINCT RTRIM(LEFT(LOWER(TRIM(T.NAME)), 64)), (EXTRACT(EPOCH FROM NOW()) * 1000)::BIGINT
+FROM POSTS P
+CROSS JOIN LATERAL UNNEST(STRING_TO_ARRAY(P.TAGS, ',')) AS T(NAME)
+WHERE TRIM(T.NAME) <> ''
+ON CONFLICT (NAME) DO NOTHING;
+
+INSERT INTO POST_TAGS (POST_ID, TAG_ID, SORT_ORDER, CREATED_AT)
+SELECT DISTINCT ON (P.ID, TG.ID) P.ID, TG.ID, T.SORT_ORDER, P.CREATED_AT
+FROM POSTS P
+CROSS JOIN LATERAL UNNEST(STRING_TO_ARRAY(P.TAGS, ',')) WITH ORDINALITY AS T(NAME, SORT_ORDER)
+JOIN TAGS TG ON TG.NAME = RTRIM(LEFT(LOWER(TRIM(T.NAME)), 64))
+ORDER BY P.ID, TG.ID, T.SORT_ORDER;
+
+ALTER TABLE POSTS DROP COLUMN TAGS;
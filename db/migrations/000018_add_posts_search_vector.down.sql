@@ -0,0 +1,4 @@
+DROP INDEX index_posts_search_vector;
+
+ALTER TABLE POSTS DROP COLUMN SEARCH_LANGUAGE;
+ALTER TABLE POSTS DROP COLUMN SEARCH_VECTOR;
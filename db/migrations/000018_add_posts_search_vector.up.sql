@@ -0,0 +1,6 @@
+ALTER TABLE POSTS ADD COLUMN SEARCH_VECTOR TSVECTOR NOT NULL DEFAULT ''::TSVECTOR;
+-- text search configuration SEARCH_VECTOR was built with, posts indexed with another
+-- configuration than SEARCH_LANGUAGE are reindexed when the server starts
+ALTER TABLE POSTS ADD COLUMN SEARCH_LANGUAGE VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX index_posts_search_vector ON POSTS USING GIN (SEARCH_VECTOR);
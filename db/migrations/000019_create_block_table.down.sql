@@ -0,0 +1,6 @@
+DROP INDEX index_blocks_blocked;
+
+ALTER TABLE BLOCKS DROP CONSTRAINT fk_blocks_blocker;
+ALTER TABLE BLOCKS DROP CONSTRAINT fk_blocks_blocked;
+
+DROP TABLE BLOCKS;
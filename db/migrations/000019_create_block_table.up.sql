@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS BLOCKS (
+    BLOCKER_ID INTEGER NOT NULL,
+    BLOCKED_ID INTEGER NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    PRIMARY KEY (BLOCKER_ID, BLOCKED_ID),
+    CONSTRAINT fk_blocks_blocker FOREIGN KEY(BLOCKER_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_blocks_blocked FOREIGN KEY(BLOCKED_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT check_blocks_users CHECK (BLOCKER_ID <> BLOCKED_ID)
+);
+
+CREATE INDEX index_blocks_blocked ON BLOCKS (BLOCKED_ID);
@@ -0,0 +1,9 @@
+DROP INDEX index_post_audiences_user;
+
+ALTER TABLE POST_AUDIENCES DROP CONSTRAINT fk_post_audiences_post;
+ALTER TABLE POST_AUDIENCES DROP CONSTRAINT fk_post_audiences_user;
+
+DROP TABLE POST_AUDIENCES;
+
+ALTER TABLE POSTS DROP CONSTRAINT check_posts_visibility;
+ALTER TABLE POSTS DROP COLUMN VISIBILITY;
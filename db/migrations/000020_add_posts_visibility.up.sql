@@ -0,0 +1,14 @@
+-- posts created before visibility existed were readable by everyone
+ALTER TABLE POSTS ADD COLUMN VISIBILITY VARCHAR(16) NOT NULL DEFAULT 'public';
+ALTER TABLE POSTS ADD CONSTRAINT check_posts_visibility CHECK (VISIBILITY IN ('public', 'friends', 'private', 'custom'));
+
+-- users allowed to see a post with the custom visibility
+CREATE TABLE IF NOT EXISTS POST_AUDIENCES (
+    POST_ID INTEGER NOT NULL,
+    USER_ID INTEGER NOT NULL,
+    PRIMARY KEY (POST_ID, USER_ID),
+    CONSTRAINT fk_post_audiences_post FOREIGN KEY(POST_ID) REFERENCES POSTS(id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_audiences_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_post_audiences_user ON POST_AUDIENCES (USER_ID);
@@ -0,0 +1,5 @@
+DROP INDEX index_password_resets_user;
+
+ALTER TABLE PASSWORD_RESETS DROP CONSTRAINT fk_password_resets_user;
+
+DROP TABLE PASSWORD_RESETS;
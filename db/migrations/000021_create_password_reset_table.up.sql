@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS PASSWORD_RESETS (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NOT NULL,
+    -- sha256 of the token sent to the user, the token itself is never stored
+    TOKEN_HASH VARCHAR(64) NOT NULL,
+    EXPIRES_AT BIGINT NOT NULL,
+    USED_AT BIGINT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_password_resets_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT unique_password_resets_token_hash UNIQUE (TOKEN_HASH)
+);
+
+CREATE INDEX index_password_resets_user ON PASSWORD_RESETS (USER_ID);
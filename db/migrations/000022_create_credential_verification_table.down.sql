@@ -0,0 +1,7 @@
+DROP INDEX index_credential_verifications_user_type;
+DROP INDEX index_credential_verifications_value;
+
+ALTER TABLE CREDENTIAL_VERIFICATIONS DROP CONSTRAINT fk_credential_verifications_user;
+ALTER TABLE CREDENTIAL_VERIFICATIONS DROP CONSTRAINT check_credential_verifications_type;
+
+DROP TABLE CREDENTIAL_VERIFICATIONS;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS CREDENTIAL_VERIFICATIONS (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NOT NULL,
+    CREDENTIAL_TYPE VARCHAR(10) NOT NULL,
+    CREDENTIAL_VALUE VARCHAR NOT NULL,
+    -- sha256 of the one-time code sent to the credential
+    CODE_HASH VARCHAR(64) NOT NULL,
+    ATTEMPTS INTEGER NOT NULL DEFAULT 0,
+    SEND_COUNT INTEGER NOT NULL DEFAULT 1,
+    EXPIRES_AT BIGINT NOT NULL,
+    SENT_AT BIGINT NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_credential_verifications_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE,
+    CONSTRAINT check_credential_verifications_type CHECK (CREDENTIAL_TYPE IN ('email', 'phone'))
+);
+
+-- a user has at most one pending credential of each type, and a credential is pending for at most one user
+CREATE UNIQUE INDEX index_credential_verifications_user_type ON CREDENTIAL_VERIFICATIONS (USER_ID, CREDENTIAL_TYPE);
+CREATE UNIQUE INDEX index_credential_verifications_value ON CREDENTIAL_VERIFICATIONS (CREDENTIAL_TYPE, CREDENTIAL_VALUE);
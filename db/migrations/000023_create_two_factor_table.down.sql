@@ -0,0 +1,10 @@
+DROP INDEX index_login_challenges_token_hash;
+DROP INDEX index_two_factor_recovery_codes_user;
+
+ALTER TABLE LOGIN_CHALLENGES DROP CONSTRAINT fk_login_challenges_user;
+ALTER TABLE TWO_FACTOR_RECOVERY_CODES DROP CONSTRAINT fk_two_factor_recovery_codes_user;
+ALTER TABLE TWO_FACTORS DROP CONSTRAINT fk_two_factors_user;
+
+DROP TABLE LOGIN_CHALLENGES;
+DROP TABLE TWO_FACTOR_RECOVERY_CODES;
+DROP TABLE TWO_FACTORS;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS TWO_FACTORS (
+    USER_ID INTEGER PRIMARY KEY,
+    -- base32 TOTP secret, it is needed in clear to compute the codes
+    SECRET VARCHAR(64) NOT NULL,
+    -- null while the enrollment is waiting for its first code
+    ENABLED_AT BIGINT NULL,
+    -- time step of the last accepted code, a code is accepted only once
+    LAST_USED_STEP BIGINT NOT NULL DEFAULT 0,
+    CREATED_AT BIGINT NOT NULL,
+    UPDATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_two_factors_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS TWO_FACTOR_RECOVERY_CODES (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NOT NULL,
+    CODE_HASH VARCHAR(64) NOT NULL,
+    USED_AT BIGINT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_two_factor_recovery_codes_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_two_factor_recovery_codes_user ON TWO_FACTOR_RECOVERY_CODES (USER_ID);
+
+-- a login waiting for its second factor
+CREATE TABLE IF NOT EXISTS LOGIN_CHALLENGES (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NOT NULL,
+    TOKEN_HASH VARCHAR(64) NOT NULL,
+    ATTEMPTS INTEGER NOT NULL DEFAULT 0,
+    EXPIRES_AT BIGINT NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_login_challenges_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX index_login_challenges_token_hash ON LOGIN_CHALLENGES (TOKEN_HASH);
@@ -0,0 +1,8 @@
+DROP INDEX index_login_attempts_last_failure_at;
+DROP INDEX index_security_events_user;
+DROP INDEX index_security_events_ip;
+
+ALTER TABLE SECURITY_EVENTS DROP CONSTRAINT fk_security_events_user;
+
+DROP TABLE SECURITY_EVENTS;
+DROP TABLE LOGIN_ATTEMPTS;
@@ -0,0 +1,24 @@
+-- failed logins per credential or ip, only used by the postgres login attempt driver
+CREATE TABLE IF NOT EXISTS LOGIN_ATTEMPTS (
+    KEY VARCHAR PRIMARY KEY,
+    FAILURES INTEGER NOT NULL,
+    LAST_FAILURE_AT BIGINT NOT NULL,
+    BLOCKED_UNTIL BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX index_login_attempts_last_failure_at ON LOGIN_ATTEMPTS (LAST_FAILURE_AT);
+
+-- audit log of authentication events, kept when the user is deleted
+CREATE TABLE IF NOT EXISTS SECURITY_EVENTS (
+    ID SERIAL PRIMARY KEY,
+    USER_ID INTEGER NULL,
+    EVENT_TYPE VARCHAR(30) NOT NULL,
+    CREDENTIAL_TYPE VARCHAR(10) NOT NULL,
+    CREDENTIAL_VALUE VARCHAR NOT NULL,
+    IP_ADDRESS VARCHAR(45) NOT NULL,
+    CREATED_AT BIGINT NOT NULL,
+    CONSTRAINT fk_security_events_user FOREIGN KEY(USER_ID) REFERENCES USERS(id) ON DELETE SET NULL
+);
+
+CREATE INDEX index_security_events_user ON SECURITY_EVENTS (USER_ID, CREATED_AT);
+CREATE INDEX index_security_events_ip ON SECURITY_EVENTS (IP_ADDRESS, CREATED_AT);
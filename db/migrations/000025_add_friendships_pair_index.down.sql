@@ -0,0 +1 @@
+DROP INDEX index_friendships_pair;
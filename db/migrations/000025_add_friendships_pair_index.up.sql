@@ -0,0 +1,17 @@
+-- keep one friendship per pair of users, an accepted one first and then the oldest
+DELETE FROM FRIENDSHIPS F USING FRIENDSHIPS O
+WHERE LEAST(F.USER_ID, F.ADDED_BY) = LEAST(O.USER_ID, O.ADDED_BY)
+    AND GREATEST(F.USER_ID, F.ADDED_BY) = GREATEST(O.USER_ID, O.ADDED_BY)
+    AND F.ID <> O.ID
+    AND (
+        (O.STATUS = 'accepted' AND F.STATUS <> 'accepted')
+        OR ((O.STATUS = 'accepted') = (F.STATUS = 'accepted') AND O.ID < F.ID)
+    );
+
+-- duplicated accepted friendships were counted more than once
+UPDATE USERS U SET FRIEND_COUNT = (
+    SELECT COUNT(*) FROM FRIENDSHIPS F
+    WHERE F.STATUS = 'accepted' AND (F.USER_ID = U.ID OR F.ADDED_BY = U.ID)
+);
+
+CREATE UNIQUE INDEX index_friendships_pair ON FRIENDSHIPS (LEAST(USER_ID, ADDED_BY), GREATEST(USER_ID, ADDED_BY));
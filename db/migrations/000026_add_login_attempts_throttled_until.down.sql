@@ -0,0 +1,3 @@
+DROP INDEX index_security_events_created_at;
+
+ALTER TABLE LOGIN_ATTEMPTS DROP COLUMN THROTTLED_UNTIL;
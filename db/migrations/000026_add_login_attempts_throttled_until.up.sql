@@ -0,0 +1,4 @@
+ALTER TABLE LOGIN_ATTEMPTS ADD COLUMN THROTTLED_UNTIL BIGINT NOT NULL DEFAULT 0;
+
+-- security events older than the retention are removed periodically
+CREATE INDEX index_security_events_created_at ON SECURITY_EVENTS (CREATED_AT);
@@ -49,11 +49,17 @@ func (m *middleware) Authentication(isThrowError bool) func(next echo.HandlerFun
 					return httpHelper.ResponseJSONHTTP(c, http.StatusForbidden, "", nil, nil, errorer.ErrForbidden)
 				}
 
+				code, err := m.service.ValidateSession(c.Request().Context(), claims.SessionID, claims.Id)
+				if err != nil {
+					return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+				}
+
 				usr, code, err := m.service.GetUserByID(c.Request().Context(), claims.Id)
 				if err != nil {
 					return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
 				}
 				c.Set(common.EncodedUserJwtCtxKey.ToString(), usr)
+				c.Set(common.SessionIDCtxKey.ToString(), claims.SessionID)
 			}
 
 			return next(c)
@@ -0,0 +1,52 @@
+package restapi
+
+import (
+	"net/http"
+	"socialapp/internal/helper/common"
+	httpHelper "socialapp/internal/helper/http"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Restapi) BlockUser(c echo.Context) error {
+	var request request.BlockUser
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.BlockUser(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) UnblockUser(c echo.Context) error {
+	var request request.UnblockUser
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.UnblockUser(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) FindAllBlocks(c echo.Context) error {
+	var request request.FindAllBlocks
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Offset = offset
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllBlocks(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
@@ -0,0 +1,78 @@
+package restapi
+
+import (
+	"net/http"
+	"socialapp/internal/helper/common"
+	httpHelper "socialapp/internal/helper/http"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Restapi) CreateConversation(c echo.Context) error {
+	var request request.CreateConversation
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, code, err := r.service.CreateConversation(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
+func (r *Restapi) FindAllConversations(c echo.Context) error {
+	var request request.FindAllConversations
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Offset = offset
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllConversations(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) SendMessage(c echo.Context) error {
+	var request request.SendMessage
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, code, err := r.service.SendMessage(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
+func (r *Restapi) FindAllMessages(c echo.Context) error {
+	var request request.FindAllMessages
+	limit, _, ok := parsePagination(c.QueryParams())
+	if !ok || limit == 0 {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.ConversationID = c.Param("id")
+	request.Limit = limit
+	request.Cursor = c.QueryParam("cursor")
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllMessages(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) ReadConversation(c echo.Context) error {
+	request := request.ReadConversation{
+		ConversationID: c.Param("id"),
+		UserID:         c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.ReadConversation(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
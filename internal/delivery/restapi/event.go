@@ -0,0 +1,178 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"socialapp/internal/helper/common"
+	httpHelper "socialapp/internal/helper/http"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/response"
+	"socialapp/internal/service"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// eventHeartbeatInterval is how often an idle stream is pinged and its session checked again
+	eventHeartbeatInterval = 25 * time.Second
+	// eventWriteTimeout bound how long a single write to a stream may block
+	eventWriteTimeout = 10 * time.Second
+	// eventRetry is the reconnect delay in milliseconds suggested to event source clients
+	eventRetry = 3000
+)
+
+// StreamEvents push the events of the user as server-sent events, resuming after the Last-Event-ID header
+func (r *Restapi) StreamEvents(c echo.Context) error {
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("lastEventId")
+	}
+
+	stream, code, err := r.subscribeEvents(c, lastEventID)
+	if err != nil {
+		r.debugError(err)
+		return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+	}
+	defer stream.Close()
+
+	res := c.Response()
+	rc := http.NewResponseController(res)
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// stop reverse proxies from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	write := func(s string) error {
+		rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		if _, err := fmt.Fprint(res, s); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if err := write(fmt.Sprintf("retry: %d\n\n", eventRetry)); err != nil {
+		return nil
+	}
+
+	send := func(ev response.Event) error {
+		data, err := json.Marshal(ev.Data)
+		if err != nil {
+			return err
+		}
+		msg := ""
+		if ev.ID != "" {
+			msg += "id: " + ev.ID + "\n"
+		}
+		return write(msg + "event: " + ev.Type + "\ndata: " + string(data) + "\n\n")
+	}
+	ping := func() error {
+		return write(": " + entity.EventTypePing + "\n\n")
+	}
+
+	r.pumpEvents(c, stream, c.Request().Context().Done(), send, ping)
+	return nil
+}
+
+// StreamEventsWebSocket push the events of the user as json messages over a websocket,
+// resuming after the lastEventId query param
+func (r *Restapi) StreamEventsWebSocket(c echo.Context) error {
+	stream, code, err := r.subscribeEvents(c, c.QueryParam("lastEventId"))
+	if err != nil {
+		r.debugError(err)
+		return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+	}
+	defer stream.Close()
+
+	server := websocket.Server{
+		Handshake: checkWebSocketOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// the client is not expected to send anything, reading only detects when it goes away
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var msg string
+				for websocket.Message.Receive(ws, &msg) == nil {
+				}
+			}()
+
+			send := func(ev response.Event) error {
+				ws.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+				return websocket.JSON.Send(ws, ev)
+			}
+			ping := func() error {
+				return send(response.Event{Type: entity.EventTypePing})
+			}
+
+			r.pumpEvents(c, stream, closed, send, ping)
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func (r *Restapi) subscribeEvents(c echo.Context, lastEventID string) (*service.EventStream, int, error) {
+	userID := c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+	return r.service.SubscribeEvents(c.Request().Context(), userID, lastEventID)
+}
+
+// pumpEvents write the replayed and live events of the stream until the client goes away,
+// the subscription is dropped for falling behind or the session is no longer valid
+func (r *Restapi) pumpEvents(c echo.Context, stream *service.EventStream, done <-chan struct{}, send func(response.Event) error, ping func() error) {
+	userID := c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+	sessionID, _ := c.Get(common.SessionIDCtxKey.ToString()).(int64)
+
+	for _, ev := range stream.Replay {
+		if err := send(ev); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(eventHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case ev, ok := <-stream.Events:
+			if !ok {
+				return
+			}
+			if err := send(stream.ToResponse(ev)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := r.service.ValidateSession(c.Request().Context(), sessionID, userID); err != nil {
+				r.debugError(err)
+				return
+			}
+			if err := ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// checkWebSocketOrigin reject cross origin handshakes authenticated only by the jwt cookie,
+// which a browser attaches to requests from any site
+func checkWebSocketOrigin(cfg *websocket.Config, req *http.Request) error {
+	if req.URL.Query().Get("jwt") != "" || req.Header.Get(echo.HeaderAuthorization) != "" {
+		return nil
+	}
+
+	origin, err := websocket.Origin(cfg, req)
+	if err != nil {
+		return err
+	}
+	if origin != nil && origin.Host != req.Host {
+		return errors.New("cross origin websocket request")
+	}
+	return nil
+}
@@ -116,3 +116,84 @@ func (r *Restapi) FindAllFriend(c echo.Context) error {
 	r.debugError(err)
 	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
 }
+
+func (r *Restapi) FindAllFriendRequest(c echo.Context) error {
+	var request request.FindAllFriendRequests
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Offset = offset
+
+	request.Type = c.QueryParam("type")
+	if request.Type == "" {
+		request.Type = "incoming"
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllFriendRequests(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) AcceptFriendRequest(c echo.Context) error {
+	request := request.FriendRequestAction{
+		RequestID: c.Param("id"),
+		UserID:    c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.AcceptFriendRequest(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) RejectFriendRequest(c echo.Context) error {
+	request := request.FriendRequestAction{
+		RequestID: c.Param("id"),
+		UserID:    c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.RejectFriendRequest(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) CancelFriendRequest(c echo.Context) error {
+	request := request.FriendRequestAction{
+		RequestID: c.Param("id"),
+		UserID:    c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.CancelFriendRequest(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) FindMutualFriends(c echo.Context) error {
+	var request request.FindMutualFriends
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Offset = offset
+	request.OtherID = c.Param("id")
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindMutualFriends(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) FindFriendSuggestions(c echo.Context) error {
+	var request request.FindFriendSuggestions
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Offset = offset
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindFriendSuggestions(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
@@ -13,10 +13,10 @@ func (r *Restapi) UploadImage(c echo.Context) error {
 		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, err)
 	}
 
-	imgUrl, code, err := r.service.UploadImage(c.Request().Context(), file)
+	ret, code, err := r.service.UploadImage(c.Request().Context(), file)
 	r.debugError(err)
 	if err != nil {
 		return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
 	}
-	return httpHelper.ResponseJSONHTTP(c, code, "File uploaded sucessfully", map[string]string{"imageUrl": imgUrl}, nil, err)
+	return httpHelper.ResponseJSONHTTP(c, code, "File uploaded sucessfully", ret, nil, err)
 }
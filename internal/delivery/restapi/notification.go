@@ -0,0 +1,51 @@
+package restapi
+
+import (
+	"net/http"
+	"socialapp/internal/helper/common"
+	httpHelper "socialapp/internal/helper/http"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Restapi) FindAllNotifications(c echo.Context) error {
+	var request request.FindAllNotifications
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Offset = offset
+
+	if c.QueryParams().Has("unreadOnly") {
+		unreadOnly := c.QueryParam("unreadOnly")
+		if unreadOnly != "true" && unreadOnly != "false" {
+			return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+		}
+		request.UnreadOnly = unreadOnly == "true"
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllNotifications(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) ReadNotification(c echo.Context) error {
+	request := request.ReadNotification{
+		NotificationID: c.Param("id"),
+		UserID:         c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.ReadNotification(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) ReadAllNotifications(c echo.Context) error {
+	userID := c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+	code, err := r.service.ReadAllNotifications(c.Request().Context(), userID)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
@@ -80,6 +80,11 @@ func (r *Restapi) FindAll(c echo.Context) error {
 			request.Tags = append(request.Tags, t)
 		}
 	}
+	request.TagMode = urlValues.Get("tagMode")
+	request.Search = urlValues.Get("search")
+	request.AuthorID = urlValues.Get("authorId")
+	request.From = urlValues.Get("from")
+	request.To = urlValues.Get("to")
 
 	userId := c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
 
@@ -89,3 +94,138 @@ func (r *Restapi) FindAll(c echo.Context) error {
 	r.debugError(err)
 	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
 }
+
+func (r *Restapi) FindPost(c echo.Context) error {
+	var request request.FindPost
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, code, err := r.service.FindPost(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
+func (r *Restapi) UpdatePost(c echo.Context) error {
+	var request request.UpdatePost
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.UpdatePost(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) DeletePost(c echo.Context) error {
+	var request request.DeletePost
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.DeletePost(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) UpdateComment(c echo.Context) error {
+	var request request.UpdateComment
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.UpdateComment(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) FindCommentRevisions(c echo.Context) error {
+	request := request.FindCommentRevisions{
+		CommentID: c.Param("id"),
+		UserID:    c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	ret, code, err := r.service.FindCommentRevisions(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
+func (r *Restapi) DeleteComment(c echo.Context) error {
+	var request request.DeleteComment
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.DeleteComment(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) FindAllComments(c echo.Context) error {
+	var request request.FindAllComments
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.PostID = c.Param("id")
+	request.Limit = limit
+	request.Offset = offset
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllComments(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) FindTimeline(c echo.Context) error {
+	var request request.FindTimeline
+
+	urlValues := c.QueryParams()
+	limit, _, ok := parsePagination(urlValues)
+	if !ok || limit == 0 {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.Limit = limit
+	request.Cursor = urlValues.Get("cursor")
+	request.Search = urlValues.Get("search")
+
+	request.Tags = []string{}
+	for _, t := range urlValues["searchTag"] {
+		if t == "" {
+			return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+		}
+		request.Tags = append(request.Tags, t)
+	}
+	request.TagMode = urlValues.Get("tagMode")
+
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindTimeline(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
+
+func (r *Restapi) FindAllReplies(c echo.Context) error {
+	var request request.FindAllReplies
+	limit, offset, ok := parsePagination(c.QueryParams())
+	if !ok {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.CommentID = c.Param("id")
+	request.Limit = limit
+	request.Offset = offset
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, meta, code, err := r.service.FindAllReplies(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, meta, err)
+}
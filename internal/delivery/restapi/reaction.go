@@ -0,0 +1,57 @@
+package restapi
+
+import (
+	"net/http"
+	"socialapp/internal/helper/common"
+	httpHelper "socialapp/internal/helper/http"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Restapi) ReactPost(c echo.Context) error {
+	var request request.ReactPost
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.ReactPost(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) UnreactPost(c echo.Context) error {
+	request := request.UnreactPost{
+		PostID: c.Param("id"),
+		UserID: c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.UnreactPost(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) ReactComment(c echo.Context) error {
+	var request request.ReactComment
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.ReactComment(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
+
+func (r *Restapi) UnreactComment(c echo.Context) error {
+	request := request.UnreactComment{
+		CommentID: c.Param("id"),
+		UserID:    c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+	code, err := r.service.UnreactComment(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+}
@@ -1,9 +1,12 @@
 package restapi
 
 import (
+	"net/url"
 	"socialapp/internal/delivery/middleware"
 	"socialapp/internal/service"
 
+	"strconv"
+
 	"github.com/rs/zerolog"
 )
 
@@ -30,3 +33,27 @@ func (r *Restapi) debugError(err error) {
 		r.log.Debug().Stack().Err(err).Send()
 	}
 }
+
+// maxPageLimit bound the page size of list endpoints, larger limits are lowered to it
+const maxPageLimit = 100
+
+// parsePagination read limit and offset query params, defaulting to limit 10 and offset 0
+func parsePagination(urlValues url.Values) (int, int, bool) {
+	limit, offset := 10, 0
+	if urlValues.Has("limit") {
+		l, err := strconv.Atoi(urlValues.Get("limit"))
+		if err != nil || l < 0 {
+			return 0, 0, false
+		}
+		limit = min(l, maxPageLimit)
+	}
+
+	if urlValues.Has("offset") {
+		o, err := strconv.Atoi(urlValues.Get("offset"))
+		if err != nil || o < 0 {
+			return 0, 0, false
+		}
+		offset = o
+	}
+	return limit, offset, true
+}
@@ -24,20 +24,72 @@ func (r *Restapi) MakeRoute(e *echo.Echo) {
 
 	// user
 	NewRoute(e, http.MethodPatch, "/v1/user", r.UpdateAccount, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/user/:id", r.GetUserProfile, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodPost, "/v1/user/link", r.LinkEmail, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodPost, "/v1/user/link/phone", r.LinkPhone, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodPost, "/v1/user/register", r.Register)
+	NewRoute(e, http.MethodPost, "/v1/user/verify", r.ConfirmCredential)
+	NewRoute(e, http.MethodPost, "/v1/user/verify/resend", r.ResendVerification)
 	NewRoute(e, http.MethodPost, "/v1/user/login", r.Login)
+	NewRoute(e, http.MethodPost, "/v1/user/login/2fa", r.LoginTwoFactor)
+	NewRoute(e, http.MethodPost, "/v1/user/2fa/enroll", r.EnrollTwoFactor, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/user/2fa/confirm", r.ConfirmTwoFactor, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/user/2fa/disable", r.DisableTwoFactor, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/user/refresh", r.RefreshToken)
+	NewRoute(e, http.MethodPost, "/v1/user/logout", r.Logout, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/user/logout/all", r.LogoutAll, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/user/password", r.ChangePassword, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/user/password/forgot", r.ForgotPassword)
+	NewRoute(e, http.MethodPost, "/v1/user/password/reset", r.ResetPassword)
 	// friendship
 	NewRoute(e, http.MethodGet, "/v1/friend", r.FindAllFriend, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodPost, "/v1/friend", r.CreateFriendship, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodDelete, "/v1/friend", r.DeleteFriendship, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/friend/request", r.FindAllFriendRequest, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/friend/request/:id/accept", r.AcceptFriendRequest, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/friend/request/:id/reject", r.RejectFriendRequest, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodDelete, "/v1/friend/request/:id", r.CancelFriendRequest, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/friend/suggestion", r.FindFriendSuggestions, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/friend/:id/mutual", r.FindMutualFriends, r.middleware.Authentication(true))
+	// block
+	NewRoute(e, http.MethodGet, "/v1/block", r.FindAllBlocks, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/block", r.BlockUser, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodDelete, "/v1/block", r.UnblockUser, r.middleware.Authentication(true))
 	// image
 	NewRoute(e, http.MethodPost, "/v1/image", r.UploadImage, r.middleware.Authentication(true))
 	// post
 	NewRoute(e, http.MethodPost, "/v1/post", r.CreatePost, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodGet, "/v1/post", r.FindAll, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/post/timeline", r.FindTimeline, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/post/:id", r.FindPost, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPatch, "/v1/post/:id", r.UpdatePost, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodDelete, "/v1/post/:id", r.DeletePost, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/post/:id/comment", r.FindAllComments, r.middleware.Authentication(true))
 	NewRoute(e, http.MethodPost, "/v1/post/comment", r.CreateComment, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPatch, "/v1/post/comment/:id", r.UpdateComment, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodDelete, "/v1/post/comment/:id", r.DeleteComment, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/post/comment/:id/revision", r.FindCommentRevisions, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/post/comment/:id/reply", r.FindAllReplies, r.middleware.Authentication(true))
+	// tag
+	NewRoute(e, http.MethodGet, "/v1/tags/trending", r.FindTrendingTags, r.middleware.Authentication(true))
+	// reaction
+	NewRoute(e, http.MethodPut, "/v1/post/:id/reaction", r.ReactPost, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodDelete, "/v1/post/:id/reaction", r.UnreactPost, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPut, "/v1/post/comment/:id/reaction", r.ReactComment, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodDelete, "/v1/post/comment/:id/reaction", r.UnreactComment, r.middleware.Authentication(true))
+	// notification
+	NewRoute(e, http.MethodGet, "/v1/notification", r.FindAllNotifications, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/notification/read", r.ReadAllNotifications, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/notification/:id/read", r.ReadNotification, r.middleware.Authentication(true))
+	// conversation
+	NewRoute(e, http.MethodGet, "/v1/conversation", r.FindAllConversations, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/conversation", r.CreateConversation, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/conversation/:id/message", r.FindAllMessages, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/conversation/:id/message", r.SendMessage, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodPost, "/v1/conversation/:id/read", r.ReadConversation, r.middleware.Authentication(true))
+	// event
+	NewRoute(e, http.MethodGet, "/v1/event", r.StreamEvents, r.middleware.Authentication(true))
+	NewRoute(e, http.MethodGet, "/v1/event/ws", r.StreamEventsWebSocket, r.middleware.Authentication(true))
 
 }
 
@@ -0,0 +1,40 @@
+package restapi
+
+import (
+	"net/http"
+	"socialapp/internal/helper/common"
+	httpHelper "socialapp/internal/helper/http"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (r *Restapi) FindTrendingTags(c echo.Context) error {
+	request := request.FindTrendingTags{
+		Limit: 10,
+		Days:  7,
+	}
+
+	urlValues := c.QueryParams()
+	if urlValues.Has("limit") {
+		limit, err := strconv.Atoi(urlValues.Get("limit"))
+		if err != nil {
+			return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+		}
+		request.Limit = min(limit, maxPageLimit)
+	}
+	if urlValues.Has("days") {
+		days, err := strconv.Atoi(urlValues.Get("days"))
+		if err != nil {
+			return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+		}
+		request.Days = days
+	}
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, code, err := r.service.FindTrendingTags(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
@@ -19,7 +19,31 @@ func (r *Restapi) Register(c echo.Context) error {
 
 	ret, code, err := r.service.Register(c.Request().Context(), request)
 	r.debugError(err)
-	return httpHelper.ResponseJSONHTTP(c, code, "User registered successfully", ret, nil, err)
+	return httpHelper.ResponseJSONHTTP(c, code, "User registered, verification code sent", ret, nil, err)
+}
+
+func (r *Restapi) ConfirmCredential(c echo.Context) error {
+	var request request.ConfirmCredential
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	ret, code, err := r.service.ConfirmCredential(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Credential verified successfully", ret, nil, err)
+}
+
+func (r *Restapi) ResendVerification(c echo.Context) error {
+	var request request.ResendVerification
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	code, err := r.service.ResendVerification(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "If the credential is pending, a new code has been sent", nil, nil, err)
 }
 
 func (r *Restapi) Login(c echo.Context) error {
@@ -28,11 +52,25 @@ func (r *Restapi) Login(c echo.Context) error {
 	if err != nil {
 		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
 	}
+
+	request.IP = c.RealIP()
+
 	ret, code, err := r.service.Login(c.Request().Context(), request)
 	r.debugError(err)
 	return httpHelper.ResponseJSONHTTP(c, code, "User logged successfully", ret, nil, err)
 }
 
+func (r *Restapi) GetUserProfile(c echo.Context) error {
+	request := request.FindUserProfile{
+		UserID:   c.Param("id"),
+		ViewerID: c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+
+	ret, code, err := r.service.GetUserProfile(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
 func (r *Restapi) UpdateAccount(c echo.Context) error {
 	var request request.UpdateAccount
 	err := c.Bind(&request)
@@ -58,7 +96,7 @@ func (r *Restapi) LinkEmail(c echo.Context) error {
 
 	_, code, err := r.service.LinkEmail(c.Request().Context(), request)
 	r.debugError(err)
-	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Verification code sent", nil, nil, err)
 }
 
 func (r *Restapi) LinkPhone(c echo.Context) error {
@@ -72,5 +110,128 @@ func (r *Restapi) LinkPhone(c echo.Context) error {
 
 	_, code, err := r.service.LinkPhone(c.Request().Context(), request)
 	r.debugError(err)
-	return httpHelper.ResponseJSONHTTP(c, code, "", nil, nil, err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Verification code sent", nil, nil, err)
+}
+
+func (r *Restapi) RefreshToken(c echo.Context) error {
+	var request request.RefreshToken
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	ret, code, err := r.service.RefreshToken(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
+func (r *Restapi) Logout(c echo.Context) error {
+	request := request.Logout{
+		SessionID: c.Get(string(common.SessionIDCtxKey)).(int64),
+		UserID:    c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID,
+	}
+
+	code, err := r.service.Logout(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "User logged out successfully", nil, nil, err)
+}
+
+func (r *Restapi) LogoutAll(c echo.Context) error {
+	userID := c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	code, err := r.service.LogoutAll(c.Request().Context(), userID)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "User logged out from all devices successfully", nil, nil, err)
+}
+
+func (r *Restapi) ChangePassword(c echo.Context) error {
+	var request request.ChangePassword
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+	request.SessionID = c.Get(string(common.SessionIDCtxKey)).(int64)
+
+	code, err := r.service.ChangePassword(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Password changed successfully", nil, nil, err)
+}
+
+func (r *Restapi) ForgotPassword(c echo.Context) error {
+	var request request.ForgotPassword
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	request.IP = c.RealIP()
+
+	code, err := r.service.ForgotPassword(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "If the account exists, a reset token has been sent", nil, nil, err)
+}
+
+func (r *Restapi) ResetPassword(c echo.Context) error {
+	var request request.ResetPassword
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	code, err := r.service.ResetPassword(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Password reset successfully", nil, nil, err)
+}
+
+func (r *Restapi) LoginTwoFactor(c echo.Context) error {
+	var request request.LoginTwoFactor
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	request.IP = c.RealIP()
+
+	ret, code, err := r.service.LoginTwoFactor(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "User logged successfully", ret, nil, err)
+}
+
+func (r *Restapi) EnrollTwoFactor(c echo.Context) error {
+	userID := c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, code, err := r.service.EnrollTwoFactor(c.Request().Context(), userID)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "", ret, nil, err)
+}
+
+func (r *Restapi) ConfirmTwoFactor(c echo.Context) error {
+	var request request.ConfirmTwoFactor
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+
+	ret, code, err := r.service.ConfirmTwoFactor(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Two-factor authentication enabled", ret, nil, err)
+}
+
+func (r *Restapi) DisableTwoFactor(c echo.Context) error {
+	var request request.DisableTwoFactor
+	err := c.Bind(&request)
+	if err != nil {
+		return httpHelper.ResponseJSONHTTP(c, http.StatusBadRequest, "", nil, nil, nil)
+	}
+
+	request.UserID = c.Get(string(common.EncodedUserJwtCtxKey)).(*response.User).ID
+	request.IP = c.RealIP()
+
+	code, err := r.service.DisableTwoFactor(c.Request().Context(), request)
+	r.debugError(err)
+	return httpHelper.ResponseJSONHTTP(c, code, "Two-factor authentication disabled", nil, nil, err)
 }
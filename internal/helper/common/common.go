@@ -7,6 +7,7 @@ type ctxKey string
 const (
 	JwtCtxKey            ctxKey = "jwtContextKey"
 	EncodedUserJwtCtxKey ctxKey = "encodedUserJwtCtxKey"
+	SessionIDCtxKey      ctxKey = "sessionIDCtxKey"
 )
 
 func (c ctxKey) ToString() string {
@@ -14,14 +15,16 @@ func (c ctxKey) ToString() string {
 }
 
 type UserClaims struct {
-	Id int64 `json:"id"`
+	Id        int64 `json:"id"`
+	SessionID int64 `json:"sid"`
 	jwt.RegisteredClaims
 }
 
 type Meta struct {
-	Limit  int
-	Offset int
-	Total  int
+	Limit      int
+	Offset     int
+	Total      int
+	NextCursor string `json:",omitempty"`
 }
 
 // regex
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor encode a keyset position (created at and id of the last item) into an opaque string
+func EncodeCursor(createdAt int64, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createdAt, 10) + ":" + strconv.FormatInt(id, 10)))
+}
+
+// DecodeCursor decode a cursor made by EncodeCursor
+func DecodeCursor(cursor string) (int64, int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 2 {
+		return 0, 0, ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
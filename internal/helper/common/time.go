@@ -6,3 +6,21 @@ import "time"
 func UnixMilliToISO8601(unixMilli int64) string {
 	return time.UnixMilli(unixMilli).Format(time.RFC3339)
 }
+
+// ParseTimeBound parse an ISO 8601 timestamp or date (YYYY-MM-DD, UTC) into unix milli.
+// A date used as an exclusive upper bound covers the whole day, so it resolves to the next midnight.
+func ParseTimeBound(value string, upper bool) (int64, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t.UnixMilli(), nil
+	}
+
+	t, err = time.Parse(time.DateOnly, value)
+	if err != nil {
+		return 0, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.UnixMilli(), nil
+}
@@ -20,6 +20,14 @@ var (
 	ErrInvalidPhone     = errors.New("invalid phone number")
 	ErrInvalidImageUrl  = errors.New("invalid image url")
 	ErrAlreadyFriend    = errors.New("already friend")
+	ErrRequestExist     = errors.New("friend request already exist")
+	ErrWrongPassword    = errors.New("wrong password")
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrInvalidCode      = errors.New("invalid or expired code")
+	ErrTooManyAttempts  = errors.New("too many attempts")
+	ErrNotVerified      = errors.New("credential not verified")
+	ErrPendingExist     = errors.New("credential already pending verification")
+	ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
 )
 
 func ErrInputRequest(err error) error {
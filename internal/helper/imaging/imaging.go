@@ -0,0 +1,142 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("file type not allowed")
+	ErrInvalidImage      = errors.New("invalid image")
+	ErrImageTooLarge     = errors.New("image dimension too large")
+)
+
+var contentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+	FormatWebP: "image/webp",
+}
+
+var extensions = map[string]string{
+	FormatJPEG: "jpg",
+	FormatPNG:  "png",
+	FormatWebP: "webp",
+}
+
+// Detect return the image format from the magic bytes at the start of a file
+func Detect(head []byte) (string, error) {
+	switch {
+	case len(head) >= 3 && bytes.Equal(head[:3], []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG, nil
+	case len(head) >= 8 && bytes.Equal(head[:8], []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return FormatPNG, nil
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return FormatWebP, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ContentType return the mime type of a format returned by Detect
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Extension return the file extension of a format returned by Detect
+func Extension(format string) string {
+	return extensions[format]
+}
+
+// Decode sniff and fully decode data. The dimension is checked from the header before decoding
+// so oversized images are rejected without allocating their pixels.
+func Decode(data []byte, maxDimension int) (image.Image, string, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var decodeConfig func([]byte) (image.Config, error)
+	var decode func([]byte) (image.Image, error)
+	switch format {
+	case FormatJPEG:
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case FormatPNG:
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	case FormatWebP:
+		decodeConfig = func(b []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) }
+	}
+
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, "", ErrInvalidImage
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, "", ErrImageTooLarge
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+	return img, format, nil
+}
+
+// OutputFormat return the format an image of the given format is re-encoded to, WebP has no
+// encoder in the standard library so it is stored as PNG to keep transparency
+func OutputFormat(format string) string {
+	if format == FormatWebP {
+		return FormatPNG
+	}
+	return format
+}
+
+// Encode write img in format. Only pixels are written, so metadata such as EXIF and GPS
+// tags of the original file are dropped.
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case FormatPNG:
+		return png.Encode(w, img)
+	}
+	return ErrUnsupportedFormat
+}
+
+// Resize scale img down so its longest side is at most size, smaller images are returned as is
+func Resize(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
@@ -0,0 +1,234 @@
+package sanitizer
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// allowedTags is the allowlist of elements kept in sanitized html, with the attributes each may carry
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"b":          {},
+	"blockquote": {},
+	"br":         {},
+	"code":       {},
+	"em":         {},
+	"h1":         {},
+	"h2":         {},
+	"h3":         {},
+	"h4":         {},
+	"h5":         {},
+	"h6":         {},
+	"hr":         {},
+	"i":          {},
+	"li":         {},
+	"ol":         {},
+	"p":          {},
+	"pre":        {},
+	"s":          {},
+	"span":       {},
+	"strong":     {},
+	"u":          {},
+	"ul":         {},
+}
+
+// droppedTags are removed together with everything inside them
+var droppedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"select":   true,
+	"svg":      true,
+	"math":     true,
+	"head":     true,
+	"title":    true,
+}
+
+var voidTags = map[string]bool{
+	"br": true,
+	"hr": true,
+}
+
+// blockTags separate words when html is projected to plain text
+var blockTags = map[string]bool{
+	"blockquote": true,
+	"br":         true,
+	"div":        true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"hr":         true,
+	"li":         true,
+	"p":          true,
+	"pre":        true,
+	"tr":         true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// SanitizeHTML keep only allowlisted tags and attributes of input, escape every text node and
+// close any element left open. Links get a normalized href and rel="nofollow noopener".
+func SanitizeHTML(input string) string {
+	var sb strings.Builder
+	var open []string
+	skipDepth := 0
+
+	z := xhtml.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		token := z.Token()
+		name := strings.ToLower(token.Data)
+
+		switch tt {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if droppedTags[name] {
+				if tt == xhtml.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			attrs, ok := allowedTags[name]
+			if skipDepth > 0 || !ok {
+				continue
+			}
+			sb.WriteString("<" + name)
+			writeAttributes(&sb, name, token.Attr, attrs)
+			sb.WriteString(">")
+			if !voidTags[name] && tt == xhtml.StartTagToken {
+				open = append(open, name)
+			}
+		case xhtml.EndTagToken:
+			if droppedTags[name] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 || voidTags[name] {
+				continue
+			}
+			// close the innermost matching element, and anything left open inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					sb.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		case xhtml.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			sb.WriteString(html.EscapeString(token.Data))
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		sb.WriteString("</" + open[i] + ">")
+	}
+	return sb.String()
+}
+
+// PlainText project html to its visible text with whitespace collapsed, used for search and previews
+func PlainText(input string) string {
+	var sb strings.Builder
+	skipDepth := 0
+
+	z := xhtml.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		token := z.Token()
+		name := strings.ToLower(token.Data)
+
+		switch tt {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken, xhtml.EndTagToken:
+			if droppedTags[name] {
+				if tt == xhtml.StartTagToken {
+					skipDepth++
+				} else if tt == xhtml.EndTagToken && skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if blockTags[name] {
+				sb.WriteString(" ")
+			}
+		case xhtml.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			sb.WriteString(token.Data)
+		}
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func writeAttributes(sb *strings.Builder, tag string, attrs []xhtml.Attribute, allowed []string) {
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !contains(allowed, key) {
+			continue
+		}
+		val := attr.Val
+		if key == "href" {
+			href, ok := normalizeURL(val)
+			if !ok {
+				continue
+			}
+			val = href
+		}
+		sb.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+
+	if tag == "a" {
+		sb.WriteString(` rel="nofollow noopener"`)
+	}
+}
+
+// normalizeURL accept absolute http(s) and mailto urls only and return them re-encoded
+func normalizeURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if u.Scheme != "mailto" && u.Host == "" {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
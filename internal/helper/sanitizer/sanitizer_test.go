@@ -0,0 +1,64 @@
+package sanitizer
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain text", "hello world", "hello world"},
+		{"allowed tags", "<p>hi <b>there</b></p>", "<p>hi <b>there</b></p>"},
+		{"script dropped with content", `<p>a</p><script>alert(1)</script><p>b</p>`, "<p>a</p><p>b</p>"},
+		{"nested script in style", `<style><script>alert(1)</script></style>ok`, "ok"},
+		{"uppercase script", `<SCRIPT>alert(1)</SCRIPT>ok`, "ok"},
+		{"event handler removed", `<p onclick="alert(1)">x</p>`, "<p>x</p>"},
+		{"img onerror removed", `<img src=x onerror=alert(1)>text`, "text"},
+		{"svg onload dropped", `<svg onload=alert(1)><circle/></svg>text`, "text"},
+		{"iframe dropped", `<iframe src="https://evil.test"></iframe>text`, "text"},
+		{"javascript href removed", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"javascript href with spaces and case", `<a href=" JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"data href removed", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"relative href removed", `<a href="/path">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"http href kept", `<a href="HTTPS://Example.COM/a?b=1&c=2">x</a>`, `<a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener">x</a>`},
+		{"mailto href kept", `<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com" rel="nofollow noopener">x</a>`},
+		{"attribute quote breakout", `<a title='"><script>alert(1)</script>'>x</a>`, `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;" rel="nofollow noopener">x</a>`},
+		{"style attribute removed", `<span style="background:url(javascript:alert(1))">x</span>`, "<span>x</span>"},
+		{"text escaped", `1 < 2 & "3"`, "1 &lt; 2 &amp; &#34;3&#34;"},
+		{"unclosed tags closed", "<p><b>bold", "<p><b>bold</b></p>"},
+		{"stray end tag ignored", "text</p>", "text"},
+		{"misnested tags closed", "<b><i>x</b>y</i>", "<b><i>x</i></b>y"},
+		{"comment removed", "a<!-- <script>alert(1)</script> -->b", "ab"},
+		{"void tags", "a<br>b<hr/>c", "a<br>b<hr>c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeHTML(tt.input); got != tt.want {
+				t.Errorf("SanitizeHTML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"tags removed", "<p>hello <b>world</b></p>", "hello world"},
+		{"blocks separate words", "<p>one</p><p>two</p>", "one two"},
+		{"script content dropped", "a<script>alert(1)</script>b", "ab"},
+		{"whitespace collapsed", "  a \n\t b  ", "a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.input); got != tt.want {
+				t.Errorf("PlainText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
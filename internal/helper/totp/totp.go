@@ -0,0 +1,98 @@
+// Package totp implement the time-based one-time passwords of RFC 6238 with the defaults
+// authenticator apps expect: HMAC-SHA1, 6 digits and a 30 seconds period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6
+	// Period is the lifetime of a code in seconds
+	Period = 30
+	// secretSize is the secret length in bytes recommended by RFC 4226
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret return a random base32 encoded secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// Step return the time step of t
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code return the code of a secret for a time step
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
+
+// Validate check code against the steps within skew of t, it return the matched step
+// so callers can refuse a code used twice
+func Validate(secret string, code string, t time.Time, skew int) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+	for i := -skew; i <= skew; i++ {
+		expected, err := Code(secret, current+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// ProvisioningURI return the otpauth URI authenticator apps read from a QR code
+func ProvisioningURI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
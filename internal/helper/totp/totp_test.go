@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 encoding of the SHA1 seed "12345678901234567890" of RFC 6238 appendix B
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 check the SHA1 vectors of RFC 6238 appendix B, truncated to 6 digits
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcSecret, code(step), 1, step, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code(step), 1, step, true},
+		{"previous step within skew", rfcSecret, code(step - 1), 1, step - 1, true},
+		{"next step within skew", rfcSecret, code(step + 1), 1, step + 1, true},
+		{"previous step without skew", rfcSecret, code(step - 1), 0, 0, false},
+		{"step outside skew", rfcSecret, code(step - 2), 1, 0, false},
+		{"wrong code", rfcSecret, "000000", 1, 0, false},
+		{"short code", rfcSecret, code(step)[:5], 1, 0, false},
+		{"invalid secret", "not base32!", code(step), 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := Validate(tt.secret, tt.code, now, tt.skew)
+			if gotOK != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate = (%d, %v), want (%d, %v)", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
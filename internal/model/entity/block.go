@@ -0,0 +1,14 @@
+package entity
+
+type Block struct {
+	BlockerID int64
+	BlockedID int64
+	User      User
+	CreatedAt int64
+}
+
+type FindAllBlockRequest struct {
+	Limit  int
+	Offset int
+	UserID int64
+}
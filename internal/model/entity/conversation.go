@@ -0,0 +1,50 @@
+package entity
+
+type Conversation struct {
+	ID int64
+	// User1ID is always the lower user id of the pair
+	User1ID int64
+	User2ID int64
+	// Friend is the participant other than the requesting user
+	Friend        User
+	LastMessage   *Message
+	UnreadCount   int
+	LastMessageAt int64
+	CreatedAt     int64
+	UpdatedAt     int64
+}
+
+// HasParticipant report whether the user is one of the two sides of the conversation
+func (c Conversation) HasParticipant(userID int64) bool {
+	return c.User1ID == userID || c.User2ID == userID
+}
+
+// OtherParticipant return the id of the side that is not userID
+func (c Conversation) OtherParticipant(userID int64) int64 {
+	if c.User1ID == userID {
+		return c.User2ID
+	}
+	return c.User1ID
+}
+
+type Message struct {
+	ID             int64
+	ConversationID int64
+	SenderID       int64
+	Content        string
+	ReadAt         *int64
+	CreatedAt      int64
+}
+
+type FindAllConversationRequest struct {
+	Limit  int
+	Offset int
+	UserID int64
+}
+
+type FindAllMessageRequest struct {
+	ConversationID  int64
+	Limit           int
+	CursorCreatedAt int64
+	CursorID        int64
+}
@@ -0,0 +1,21 @@
+package entity
+
+const (
+	CredentialTypeEmail = "email"
+	CredentialTypePhone = "phone"
+)
+
+// CredentialVerification is an email or phone waiting for the user to confirm the code sent to it,
+// it is copied to the user once confirmed
+type CredentialVerification struct {
+	ID              int64
+	UserID          int64
+	CredentialType  string
+	CredentialValue string
+	CodeHash        string
+	Attempts        int
+	SendCount       int
+	ExpiresAt       int64
+	SentAt          int64
+	CreatedAt       int64
+}
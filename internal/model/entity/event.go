@@ -0,0 +1,23 @@
+package entity
+
+const (
+	EventTypePostCreated          = "post.created"
+	EventTypeCommentCreated       = "comment.created"
+	EventTypeFriendRequestCreated = "friend_request.created"
+	EventTypeFriendshipCreated    = "friendship.created"
+	EventTypeMessageCreated       = "message.created"
+	EventTypeMessageRead          = "message.read"
+	// EventTypeReset tells a resuming client that events were missed and it should refetch
+	EventTypeReset = "reset"
+	// EventTypePing is sent periodically to keep idle connections open
+	EventTypePing = "ping"
+)
+
+type Event struct {
+	ID     int64
+	UserID int64
+	Type   string
+	// Data is the json payload delivered to the client
+	Data      interface{}
+	CreatedAt int64
+}
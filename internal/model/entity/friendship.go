@@ -1,5 +1,10 @@
 package entity
 
+const (
+	FriendshipStatusPending  = "pending"
+	FriendshipStatusAccepted = "accepted"
+)
+
 type FindAllFriendshipRequest struct {
 	Limit      int
 	Offset     int
@@ -10,10 +15,44 @@ type FindAllFriendshipRequest struct {
 	UserID     int64
 }
 
+type FindAllFriendRequestRequest struct {
+	Limit    int
+	Offset   int
+	Incoming bool
+	UserID   int64
+}
+
+// FindMutualFriendRequest list the friends UserID and OtherID have in common
+type FindMutualFriendRequest struct {
+	Limit   int
+	Offset  int
+	UserID  int64
+	OtherID int64
+}
+
+type FindFriendSuggestionRequest struct {
+	Limit  int
+	Offset int
+	UserID int64
+}
+
 type Friendship struct {
 	ID        int64
 	UserID    int64
 	AddedBy   int64
+	Status    string
 	CreatedAt int64
 	UpdatedAt int64
 }
+
+type FriendRequest struct {
+	ID        int64
+	User      User
+	CreatedAt int64
+}
+
+// FriendSuggestion is a friend of a friend the user has no friendship or pending request with
+type FriendSuggestion struct {
+	User
+	MutualFriendCount int64
+}
@@ -0,0 +1,31 @@
+package entity
+
+// LoginAttempt is the count of recent failed logins of a credential or an ip
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt int64
+	// BlockedUntil is when logins are accepted again, zero when they are not blocked
+	BlockedUntil int64
+	// ThrottledUntil is the BlockedUntil of the last refused login, so a block is audited only once
+	ThrottledUntil int64
+}
+
+const (
+	SecurityEventLoginFailed    = "login_failed"
+	SecurityEventLoginThrottled = "login_throttled"
+	SecurityEventLoginLocked    = "login_locked"
+	// SecurityEventTwoFactorFailed is a wrong TOTP or recovery code on the second login step
+	SecurityEventTwoFactorFailed = "two_factor_failed"
+)
+
+// SecurityEvent is an entry of the authentication audit log, UserID is zero when no user matched
+type SecurityEvent struct {
+	ID              int64
+	UserID          int64
+	EventType       string
+	CredentialType  string
+	CredentialValue string
+	IPAddress       string
+	CreatedAt       int64
+}
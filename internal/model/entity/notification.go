@@ -0,0 +1,28 @@
+package entity
+
+const (
+	NotificationTypeFriendRequest  = "friend_request"
+	NotificationTypeFriendAccepted = "friend_accepted"
+	NotificationTypeComment        = "comment"
+	NotificationTypeReply          = "reply"
+)
+
+type Notification struct {
+	ID     int64
+	UserID int64
+	// ActorID is the user whose action triggered the notification
+	ActorID int64
+	Actor   User
+	Type    string
+	// ReferenceID is the friend request id for friendship notifications and the post id for comments and replies
+	ReferenceID int64
+	ReadAt      *int64
+	CreatedAt   int64
+}
+
+type FindAllNotificationRequest struct {
+	Limit      int
+	Offset     int
+	UnreadOnly bool
+	UserID     int64
+}
@@ -0,0 +1,15 @@
+package entity
+
+const (
+	MessageChannelEmail = "email"
+	MessageChannelPhone = "phone"
+)
+
+// OutgoingMessage is a message sent to a user outside of the app, by email or text message
+type OutgoingMessage struct {
+	Channel string
+	// To is the email address or phone number of the recipient
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,10 @@
+package entity
+
+type PasswordReset struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt int64
+	UsedAt    *int64
+	CreatedAt int64
+}
@@ -1,30 +1,99 @@
 package entity
 
+const (
+	PostVisibilityPublic  = "public"
+	PostVisibilityFriends = "friends"
+	PostVisibilityPrivate = "private"
+	// PostVisibilityCustom limit a post to the users listed in its audience
+	PostVisibilityCustom = "custom"
+)
+
+const (
+	// SnippetMatchStart and SnippetMatchEnd surround the matched words in a search snippet
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
 type Post struct {
 	ID          int64
 	ContentHtml string
-	Tags        string
+	ContentText string
+	Tags        []string
+	Visibility  string
 	UserID      int64
 	Creator     User
 	Comments    []Comment
-	CreatedAt   int64
-	UpdatedAt   int64
+	Reactions   Reactions
+	// Audience is the users allowed to see a post with the custom visibility, only set on write
+	Audience []int64
+	// MyReaction is the reaction of the requesting user, empty when they have not reacted
+	MyReaction string
+	// Snippet is the plain text excerpt around the search matches, empty when not searching
+	Snippet   string
+	CreatedAt int64
+	UpdatedAt int64
 }
 
 type Comment struct {
+	ID      int64
+	Content string
+	Creator User
+	PostID  int64
+	UserID  int64
+	// ParentID is the comment this one replies to, zero for top-level comments
+	ParentID   int64
+	Depth      int
+	ReplyCount int64
+	Reactions  Reactions
+	// MyReaction is the reaction of the requesting user, empty when they have not reacted
+	MyReaction string
+	CreatedAt  int64
+	UpdatedAt  int64
+	EditedAt   *int64
+	// DeletedAt is set once the comment is deleted, it is then kept as a tombstone without content
+	DeletedAt *int64
+}
+
+// CommentRevision is a previous version of a comment, saved on every edit and on deletion
+type CommentRevision struct {
 	ID        int64
+	CommentID int64
 	Content   string
-	Creator   User
-	PostID    int64
-	UserID    int64
+	EditedBy  int64
 	CreatedAt int64
-	UpdatedAt int64
 }
 
+// FindAllPostRequest list posts newest first, or by relevance when Search is set.
+// AuthorID, CreatedFrom and CreatedTo are ignored when zero.
 type FindAllPostRequest struct {
-	Limit  int
-	Offset int
-	Search string
-	Tags   []string
-	UserID int64
+	Limit        int
+	Offset       int
+	Search       string
+	Tags         []string
+	TagMode      string
+	AuthorID     int64
+	CreatedFrom  int64
+	CreatedTo    int64
+	UserID       int64
+	CommentLimit int
+}
+
+type FindTimelineRequest struct {
+	Limit           int
+	CursorCreatedAt int64
+	CursorID        int64
+	Search          string
+	Tags            []string
+	TagMode         string
+	UserID          int64
+	CommentLimit    int
+}
+
+// FindAllCommentRequest list the top-level comments of PostID, or the replies of ParentID when it is set
+type FindAllCommentRequest struct {
+	Limit    int
+	Offset   int
+	PostID   int64
+	ParentID int64
+	ViewerID int64
 }
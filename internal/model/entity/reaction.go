@@ -0,0 +1,14 @@
+package entity
+
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+)
+
+// Reactions is the number of reactions of each type on a post or comment
+type Reactions struct {
+	Like  int64
+	Love  int64
+	Laugh int64
+}
@@ -0,0 +1,11 @@
+package entity
+
+type Session struct {
+	ID               int64
+	UserID           int64
+	RefreshTokenHash string
+	ExpiresAt        int64
+	RevokedAt        *int64
+	CreatedAt        int64
+	UpdatedAt        int64
+}
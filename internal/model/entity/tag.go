@@ -0,0 +1,14 @@
+package entity
+
+const (
+	// TagModeAnd match posts carrying every requested tag
+	TagModeAnd = "and"
+	// TagModeOr match posts carrying any of the requested tags
+	TagModeOr = "or"
+)
+
+type TrendingTag struct {
+	Name       string
+	PostCount  int64
+	LastUsedAt int64
+}
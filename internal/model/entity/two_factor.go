@@ -0,0 +1,21 @@
+package entity
+
+// TwoFactor is the TOTP setup of a user, it only protects logins once EnabledAt is set
+type TwoFactor struct {
+	UserID       int64
+	Secret       string
+	EnabledAt    *int64
+	LastUsedStep int64
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+// LoginChallenge is a login that checked the password and waits for the second factor
+type LoginChallenge struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	Attempts  int
+	ExpiresAt int64
+	CreatedAt int64
+}
@@ -11,3 +11,11 @@ type User struct {
 	CreatedAt   int64
 	UpdatedAt   int64
 }
+
+// UserProfile is a user as seen by Viewer, with the stats shown on their profile
+type UserProfile struct {
+	User
+	PostCount         int64
+	IsFriend          bool
+	MutualFriendCount int64
+}
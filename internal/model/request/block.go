@@ -0,0 +1,17 @@
+package request
+
+type BlockUser struct {
+	BlockedID string `json:"userId" validate:"required"`
+	UserID    int64
+}
+
+type UnblockUser struct {
+	BlockedID string `json:"userId" validate:"required"`
+	UserID    int64
+}
+
+type FindAllBlocks struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+	UserID int64
+}
@@ -0,0 +1,30 @@
+package request
+
+type CreateConversation struct {
+	FriendID string `json:"userId" validate:"required"`
+	UserID   int64
+}
+
+type FindAllConversations struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+	UserID int64
+}
+
+type SendMessage struct {
+	ConversationID string `param:"id" validate:"required"`
+	Content        string `json:"message" validate:"required,min=1,max=2000"`
+	UserID         int64
+}
+
+type FindAllMessages struct {
+	ConversationID string `param:"id" validate:"required"`
+	Limit          int    `query:"limit"`
+	Cursor         string `query:"cursor"`
+	UserID         int64
+}
+
+type ReadConversation struct {
+	ConversationID string `param:"id" validate:"required"`
+	UserID         int64
+}
@@ -19,3 +19,28 @@ type DeleteFriendship struct {
 	Friend1 string `json:"userId" validate:"required"`
 	Friend2 int64
 }
+
+type FindAllFriendRequests struct {
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+	Type   string `query:"type" validate:"oneof=incoming outgoing"`
+	UserID int64
+}
+
+type FriendRequestAction struct {
+	RequestID string `param:"id" validate:"required"`
+	UserID    int64
+}
+
+type FindMutualFriends struct {
+	Limit   int    `query:"limit"`
+	Offset  int    `query:"offset"`
+	OtherID string `param:"id" validate:"required"`
+	UserID  int64
+}
+
+type FindFriendSuggestions struct {
+	Limit  int `query:"limit" validate:"max=50"`
+	Offset int `query:"offset"`
+	UserID int64
+}
@@ -0,0 +1,13 @@
+package request
+
+type FindAllNotifications struct {
+	Limit      int  `query:"limit"`
+	Offset     int  `query:"offset"`
+	UnreadOnly bool `query:"unreadOnly"`
+	UserID     int64
+}
+
+type ReadNotification struct {
+	NotificationID string `param:"id" validate:"required"`
+	UserID         int64
+}
@@ -3,19 +3,112 @@ package request
 type CreatePost struct {
 	ContentHtml string   `json:"postInHtml" validate:"required,min=2,max=500"`
 	Tags        []string `json:"tags" validate:"required"`
-	UserID      int64
+	// Visibility defaults to public, Audience lists the user ids allowed to see a custom post
+	Visibility string   `json:"visibility" validate:"omitempty,oneof=public friends private custom"`
+	Audience   []string `json:"audience" validate:"max=500"`
+	UserID     int64
 }
 
 type CreateComment struct {
 	Content string `json:"comment" validate:"required,min=2,max=500"`
 	PostID  string `json:"postId" validate:"required"`
-	UserID  int64
+	// ParentID is the comment being replied to, empty for a top-level comment
+	ParentID string `json:"parentCommentId"`
+	UserID   int64
 }
 
 type FindAllPost struct {
 	Limit  int      `query:"limit"`
 	Offset int      `query:"offset"`
-	Search string   `query:"search"`
+	Search string   `query:"search" validate:"max=200"`
+	Tags   []string `query:"searchTag"`
+	// TagMode is "and" to match posts having every tag or "or" to match posts having any of them
+	TagMode  string `query:"tagMode" validate:"omitempty,oneof=and or"`
+	AuthorID string `query:"authorId" validate:"omitempty,numeric"`
+	// From and To bound the creation time, as an ISO 8601 timestamp or a YYYY-MM-DD date
+	From   string `query:"from"`
+	To     string `query:"to"`
+	UserID int64
+}
+
+type FindTimeline struct {
+	Limit  int      `query:"limit"`
+	Cursor string   `query:"cursor"`
+	Search string   `query:"search" validate:"max=200"`
 	Tags   []string `query:"searchTag"`
+	// TagMode is "and" to match posts having every tag or "or" to match posts having any of them
+	TagMode string `query:"tagMode" validate:"omitempty,oneof=and or"`
+	UserID  int64
+}
+
+type FindPost struct {
+	PostID string `param:"id" validate:"required"`
 	UserID int64
 }
+
+type UpdatePost struct {
+	PostID      string   `param:"id" validate:"required"`
+	ContentHtml string   `json:"postInHtml" validate:"required,min=2,max=500"`
+	Tags        []string `json:"tags" validate:"required"`
+	// Visibility and Audience are left unchanged when Visibility is empty
+	Visibility string   `json:"visibility" validate:"omitempty,oneof=public friends private custom"`
+	Audience   []string `json:"audience" validate:"max=500"`
+	UserID     int64
+}
+
+type DeletePost struct {
+	PostID string `param:"id" validate:"required"`
+	UserID int64
+}
+
+type UpdateComment struct {
+	CommentID string `param:"id" validate:"required"`
+	Content   string `json:"comment" validate:"required,min=2,max=500"`
+	UserID    int64
+}
+
+type FindCommentRevisions struct {
+	CommentID string `param:"id" validate:"required"`
+	UserID    int64
+}
+
+type DeleteComment struct {
+	CommentID string `param:"id" validate:"required"`
+	UserID    int64
+}
+
+type FindAllComments struct {
+	PostID string `param:"id" validate:"required"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+	UserID int64
+}
+
+type FindAllReplies struct {
+	CommentID string `param:"id" validate:"required"`
+	Limit     int    `query:"limit"`
+	Offset    int    `query:"offset"`
+	UserID    int64
+}
+
+type ReactPost struct {
+	PostID string `param:"id" validate:"required"`
+	Type   string `json:"type" validate:"required,oneof=like love laugh"`
+	UserID int64
+}
+
+type UnreactPost struct {
+	PostID string `param:"id" validate:"required"`
+	UserID int64
+}
+
+type ReactComment struct {
+	CommentID string `param:"id" validate:"required"`
+	Type      string `json:"type" validate:"required,oneof=like love laugh"`
+	UserID    int64
+}
+
+type UnreactComment struct {
+	CommentID string `param:"id" validate:"required"`
+	UserID    int64
+}
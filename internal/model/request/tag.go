@@ -0,0 +1,8 @@
+package request
+
+type FindTrendingTags struct {
+	Limit int `query:"limit" validate:"min=1,max=50"`
+	// Days is how far back post usage is counted
+	Days   int `query:"days" validate:"min=1,max=30"`
+	UserID int64
+}
@@ -11,6 +11,7 @@ type Login struct {
 	CredentialType  string `json:"credentialType" validate:"required"`
 	CredentialValue string `json:"credentialValue" validate:"required"`
 	Password        string `json:"password" validate:"required,min=5,max=15"`
+	IP              string
 }
 
 type UpdateAccount struct {
@@ -28,3 +29,66 @@ type LinkEmail struct {
 	ID    int64  `validate:"required"`
 	Email string `json:"email" validate:"required"`
 }
+
+type RefreshToken struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type Logout struct {
+	SessionID int64 `validate:"required"`
+	UserID    int64 `validate:"required"`
+}
+
+type FindUserProfile struct {
+	UserID   string `param:"id" validate:"required"`
+	ViewerID int64
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=5,max=15"`
+	UserID          int64  `validate:"required"`
+	SessionID       int64  `validate:"required"`
+}
+
+type ForgotPassword struct {
+	CredentialType  string `json:"credentialType" validate:"required,oneof=email phone"`
+	CredentialValue string `json:"credentialValue" validate:"required"`
+	IP              string
+}
+
+type ResetPassword struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=5,max=15"`
+}
+
+type ConfirmCredential struct {
+	CredentialType  string `json:"credentialType" validate:"required,oneof=email phone"`
+	CredentialValue string `json:"credentialValue" validate:"required"`
+	Code            string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ResendVerification struct {
+	CredentialType  string `json:"credentialType" validate:"required,oneof=email phone"`
+	CredentialValue string `json:"credentialValue" validate:"required"`
+}
+
+type ConfirmTwoFactor struct {
+	UserID int64  `validate:"required"`
+	Code   string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// DisableTwoFactor require the password and a TOTP or recovery code of the user
+type DisableTwoFactor struct {
+	UserID   int64  `validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=30"`
+	IP       string
+}
+
+// LoginTwoFactor complete a login with the token returned by Login and a TOTP or recovery code
+type LoginTwoFactor struct {
+	Token string `json:"token" validate:"required"`
+	Code  string `json:"code" validate:"required,max=30"`
+	IP    string
+}
@@ -0,0 +1,6 @@
+package response
+
+type Block struct {
+	User      FindAllFriendships `json:"user"`
+	CreatedAt string             `json:"createdAt"`
+}
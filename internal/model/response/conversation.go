@@ -0,0 +1,30 @@
+package response
+
+type Conversation struct {
+	ConversationID string           `json:"conversationId"`
+	User           ConversationUser `json:"user"`
+	LastMessage    *Message         `json:"lastMessage"`
+	UnreadCount    int              `json:"unreadCount"`
+	CreatedAt      string           `json:"createdAt"`
+}
+
+type ConversationUser struct {
+	UserID   string `json:"userId"`
+	Name     string `json:"name"`
+	ImageURL string `json:"imageUrl"`
+}
+
+type Message struct {
+	MessageID      string `json:"messageId"`
+	ConversationID string `json:"conversationId"`
+	SenderID       string `json:"senderId"`
+	Content        string `json:"message"`
+	Read           bool   `json:"read"`
+	ReadAt         string `json:"readAt,omitempty"`
+	CreatedAt      string `json:"createdAt"`
+}
+
+type MessageReadEvent struct {
+	ConversationID string `json:"conversationId"`
+	UserID         string `json:"userId"`
+}
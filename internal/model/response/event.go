@@ -0,0 +1,28 @@
+package response
+
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt string      `json:"createdAt"`
+}
+
+type PostEvent struct {
+	PostID string `json:"postId"`
+	UserID string `json:"userId"`
+}
+
+type CommentEvent struct {
+	PostID    string `json:"postId"`
+	CommentID string `json:"commentId"`
+	UserID    string `json:"userId"`
+}
+
+type FriendRequestEvent struct {
+	RequestID string `json:"requestId"`
+	UserID    string `json:"userId"`
+}
+
+type FriendshipEvent struct {
+	UserID string `json:"userId"`
+}
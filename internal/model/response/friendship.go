@@ -7,3 +7,14 @@ type FindAllFriendships struct {
 	FriendCount int64  `json:"friendCount"`
 	CreatedAt   string `json:"createdAt"`
 }
+
+type FriendRequest struct {
+	ID        string             `json:"requestId"`
+	User      FindAllFriendships `json:"user"`
+	CreatedAt string             `json:"createdAt"`
+}
+
+type FriendSuggestion struct {
+	FindAllFriendships
+	MutualFriendCount int64 `json:"mutualFriendCount"`
+}
@@ -0,0 +1,15 @@
+package response
+
+const (
+	ImageVariantsReady   = "ready"
+	ImageVariantsPending = "pending"
+)
+
+type Image struct {
+	ImageUrl string `json:"imageUrl"`
+	// VariantStatus is pending while the resized copies are made in the background,
+	// Variants is only set once they are ready
+	VariantStatus string `json:"variantStatus"`
+	// Variants map the longest side in pixels of each resized copy to its url
+	Variants map[string]string `json:"variants,omitempty"`
+}
@@ -0,0 +1,21 @@
+package response
+
+type Notifications struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unreadCount"`
+}
+
+type Notification struct {
+	ID          string            `json:"notificationId"`
+	Type        string            `json:"type"`
+	Actor       NotificationActor `json:"actor"`
+	ReferenceID string            `json:"referenceId"`
+	Read        bool              `json:"read"`
+	CreatedAt   string            `json:"createdAt"`
+}
+
+type NotificationActor struct {
+	UserID   string `json:"userId"`
+	Name     string `json:"name"`
+	ImageURL string `json:"imageUrl"`
+}
@@ -1,27 +1,65 @@
 package response
 
 type GetPosts struct {
-	PostID string `json:"postId"`
-	Post   struct {
-		PostInHtml string   `json:"postInHtml"`
-		Tags       []string `json:"tags"`
-		CreatedAt  string   `json:"createdAt"`
-	} `json:"post"`
-	Comments []struct {
-		Comment string `json:"comment"`
-		Creator struct {
-			UserID      string `json:"userId"`
-			Name        string `json:"name"`
-			ImageURL    string `json:"imageUrl"`
-			FriendCount int    `json:"friendCount"`
-		} `json:"creator"`
-		CreatedAt string `json:"createdAt"`
-	} `json:"comments"`
-	Creator struct {
-		UserID      string `json:"userId"`
-		Name        string `json:"name"`
-		ImageURL    string `json:"imageUrl"`
-		FriendCount int    `json:"friendCount"`
-		CreatedAt   string `json:"createdAt"`
-	} `json:"creator"`
+	PostID     string      `json:"postId"`
+	Post       Post        `json:"post"`
+	Comments   []Comment   `json:"comments"`
+	Creator    PostCreator `json:"creator"`
+	Reactions  Reactions   `json:"reactions"`
+	Reacted    bool        `json:"reacted"`
+	MyReaction string      `json:"myReaction,omitempty"`
+	// Snippet is the html escaped excerpt of a search result with the matches wrapped in <mark>
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type Post struct {
+	PostInHtml string   `json:"postInHtml"`
+	Tags       []string `json:"tags"`
+	Visibility string   `json:"visibility"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type PostCreator struct {
+	UserID      string `json:"userId"`
+	Name        string `json:"name"`
+	ImageURL    string `json:"imageUrl"`
+	FriendCount int    `json:"friendCount"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// Comment is rendered as a tombstone without content or creator once Deleted is set,
+// so its replies keep their place in the thread
+type Comment struct {
+	CommentID  string         `json:"commentId"`
+	Comment    string         `json:"comment"`
+	Creator    CommentCreator `json:"creator"`
+	ParentID   string         `json:"parentCommentId,omitempty"`
+	ReplyCount int64          `json:"replyCount"`
+	Reactions  Reactions      `json:"reactions"`
+	Reacted    bool           `json:"reacted"`
+	MyReaction string         `json:"myReaction,omitempty"`
+	Edited     bool           `json:"edited"`
+	EditedAt   string         `json:"editedAt,omitempty"`
+	Deleted    bool           `json:"deleted"`
+	CreatedAt  string         `json:"createdAt"`
+}
+
+type CommentRevision struct {
+	RevisionID string `json:"revisionId"`
+	Comment    string `json:"comment"`
+	EditedBy   string `json:"editedBy"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+type CommentCreator struct {
+	UserID      string `json:"userId"`
+	Name        string `json:"name"`
+	ImageURL    string `json:"imageUrl"`
+	FriendCount int    `json:"friendCount"`
+}
+
+type Reactions struct {
+	Like  int64 `json:"like"`
+	Love  int64 `json:"love"`
+	Laugh int64 `json:"laugh"`
 }
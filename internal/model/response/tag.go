@@ -0,0 +1,7 @@
+package response
+
+type TrendingTag struct {
+	Tag       string `json:"tag"`
+	PostCount int64  `json:"postCount"`
+	LastUsed  string `json:"lastUsedAt"`
+}
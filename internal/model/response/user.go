@@ -12,15 +12,48 @@ type User struct {
 }
 
 type Register struct {
-	Email       string `json:"email,omitempty"`
-	Phone       string `json:"phone,omitempty"`
-	Name        string `json:"name"`
-	AccessToken string `json:"accessToken"`
+	Name string `json:"name"`
+	// VerificationRequired tell the client to confirm the code sent to the credential before logging in
+	VerificationRequired bool `json:"verificationRequired"`
 }
 
+// Login carry the tokens of the new session, or only TwoFactorToken when the user must
+// complete the login with a second factor
 type Login struct {
-	Email       string `json:"email"`
-	Phone       string `json:"phone"`
-	Name        string `json:"name"`
-	AccessToken string `json:"accessToken"`
+	Email             string `json:"email"`
+	Phone             string `json:"phone"`
+	Name              string `json:"name"`
+	AccessToken       string `json:"accessToken,omitempty"`
+	RefreshToken      string `json:"refreshToken,omitempty"`
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	TwoFactorToken    string `json:"twoFactorToken,omitempty"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// TwoFactorRecoveryCodes are shown once, only their hash is stored
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type Token struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// UserProfile is the public view of a user, Email and Phone are only set when users view their own profile
+type UserProfile struct {
+	UserID            string `json:"userId"`
+	Name              string `json:"name"`
+	ImageURL          string `json:"imageUrl"`
+	FriendCount       int64  `json:"friendCount"`
+	PostCount         int64  `json:"postCount"`
+	IsFriend          bool   `json:"isFriend"`
+	MutualFriendCount int64  `json:"mutualFriendCount"`
+	Email             string `json:"email,omitempty"`
+	Phone             string `json:"phone,omitempty"`
+	CreatedAt         string `json:"createdAt"`
 }
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	BlobDriverS3     = "s3"
+	BlobDriverLocal  = "local"
+	BlobDriverMemory = "memory"
+)
+
+// BlobRepository store uploaded files and return the url they are served from
+type BlobRepository interface {
+	UploadFile(ctx context.Context, key string, body io.Reader, contentType string) (string, int, error)
+	// URL return the url a file is served from once uploaded under key
+	URL(key string) string
+}
+
+type BlobConfig struct {
+	Driver string
+	// local driver
+	LocalDir       string
+	LocalPublicURL string
+	// s3 driver, Endpoint is only needed for s3 compatible storage such as MinIO
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+func NewBlobRepository(logger zerolog.Logger, cfg BlobConfig) (BlobRepository, error) {
+	switch cfg.Driver {
+	case BlobDriverS3:
+		return NewS3BlobRepository(logger, cfg)
+	case BlobDriverLocal:
+		return NewLocalBlobRepository(logger, cfg)
+	case BlobDriverMemory:
+		return NewMemoryBlobRepository(logger), nil
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"socialapp/internal/helper/errorer"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// NewLocalBlobRepository store files under cfg.LocalDir, the server is expected to serve
+// that directory at cfg.LocalPublicURL
+func NewLocalBlobRepository(logger zerolog.Logger, cfg BlobConfig) (BlobRepository, error) {
+	if err := os.MkdirAll(cfg.LocalDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalBlobRepositoryImpl{
+		logger:    logger,
+		dir:       cfg.LocalDir,
+		publicURL: strings.TrimRight(cfg.LocalPublicURL, "/"),
+	}, nil
+}
+
+type LocalBlobRepositoryImpl struct {
+	logger    zerolog.Logger
+	dir       string
+	publicURL string
+}
+
+func (s *LocalBlobRepositoryImpl) UploadFile(ctx context.Context, key string, body io.Reader, contentType string) (string, int, error) {
+	name := filepath.Clean("/" + key)[1:]
+	if name == "" {
+		return "", http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "invalid file name")
+	}
+
+	path := filepath.Join(s.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		s.logger.Debug().Msg(err.Error())
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	return s.URL(filepath.ToSlash(name)), http.StatusOK, nil
+}
+
+func (s *LocalBlobRepositoryImpl) URL(key string) string {
+	return s.publicURL + "/" + strings.TrimLeft(key, "/")
+}
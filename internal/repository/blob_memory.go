@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// NewMemoryBlobRepository keep files in memory, meant for tests and throwaway environments
+func NewMemoryBlobRepository(logger zerolog.Logger) *MemoryBlobRepositoryImpl {
+	return &MemoryBlobRepositoryImpl{
+		logger:  logger,
+		objects: map[string]MemoryBlob{},
+	}
+}
+
+type MemoryBlob struct {
+	Data        []byte
+	ContentType string
+}
+
+type MemoryBlobRepositoryImpl struct {
+	logger  zerolog.Logger
+	mu      sync.RWMutex
+	objects map[string]MemoryBlob
+}
+
+func (s *MemoryBlobRepositoryImpl) UploadFile(ctx context.Context, key string, body io.Reader, contentType string) (string, int, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, body); err != nil {
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	s.mu.Lock()
+	s.objects[key] = MemoryBlob{Data: buf.Bytes(), ContentType: contentType}
+	s.mu.Unlock()
+
+	return s.URL(key), http.StatusOK, nil
+}
+
+func (s *MemoryBlobRepositoryImpl) URL(key string) string {
+	return "memory://" + key
+}
+
+// Get return a stored file
+func (s *MemoryBlobRepositoryImpl) Get(key string) (MemoryBlob, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob, ok := s.objects[key]
+	return blob, ok
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"socialapp/internal/helper/errorer"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func NewS3BlobRepository(logger zerolog.Logger, cfg BlobConfig) (BlobRepository, error) {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(cfg.S3Region)}
+	if cfg.S3AccessKey != "" {
+		creds := credentials.NewStaticCredentialsProvider(cfg.S3AccessKey, cfg.S3SecretKey, "")
+		opts = append(opts, config.WithCredentialsProvider(creds))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.S3Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.S3Endpoint)
+		}
+		o.UsePathStyle = cfg.S3PathStyle
+	})
+
+	return &S3BlobRepositoryImpl{
+		logger:      logger,
+		bucket:      cfg.S3Bucket,
+		region:      cfg.S3Region,
+		endpoint:    strings.TrimRight(cfg.S3Endpoint, "/"),
+		pathStyle:   cfg.S3PathStyle,
+		awsS3Client: client,
+	}, nil
+}
+
+type S3BlobRepositoryImpl struct {
+	logger      zerolog.Logger
+	bucket      string
+	region      string
+	endpoint    string
+	pathStyle   bool
+	awsS3Client *s3.Client
+}
+
+func (s *S3BlobRepositoryImpl) UploadFile(ctx context.Context, key string, body io.Reader, contentType string) (string, int, error) {
+	uploader := manager.NewUploader(s.awsS3Client)
+	result, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ACL:         "public-read",
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+
+	if err != nil {
+		s.logger.Debug().Msg(err.Error())
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	return result.Location, http.StatusOK, nil
+}
+
+func (s *S3BlobRepositoryImpl) URL(key string) string {
+	if s.endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
+	}
+	if s.pathStyle {
+		return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key)
+	}
+
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key)
+	}
+	u.Host = s.bucket + "." + u.Host
+	return u.String() + "/" + key
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type BlockRepository interface {
+	Block(ctx context.Context, blockerID int64, blockedID int64) (int, error)
+	Unblock(ctx context.Context, blockerID int64, blockedID int64) (int, error)
+	IsBlocked(ctx context.Context, user1 int64, user2 int64) (bool, int, error)
+	FindAll(ctx context.Context, filter entity.FindAllBlockRequest) ([]entity.Block, *common.Meta, int, error)
+}
+
+func NewBlockRepository(logger zerolog.Logger, db *sql.DB) BlockRepository {
+	return &BlockRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type BlockRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+// blockedIDsQuery select the id of every user the user bound to placeholder blocked or was blocked by,
+// content is hidden in both directions
+func blockedIDsQuery(placeholder string) string {
+	return `SELECT b.blocked_id FROM blocks b WHERE b.blocker_id = ` + placeholder + `
+		UNION ALL
+		SELECT b.blocker_id FROM blocks b WHERE b.blocked_id = ` + placeholder
+}
+
+// isBlocked report whether either user blocked the other
+func isBlocked(ctx context.Context, q rowQueryer, user1 int64, user2 int64) (bool, error) {
+	var blocked bool
+	err := q.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))",
+		user1, user2,
+	).Scan(&blocked)
+	return blocked, err
+}
+
+// Block store the block and remove any friendship or pending request between the two users,
+// decreasing the friend count of both when they were friends. Blocking twice is a no-op.
+func (r *BlockRepositoryImpl) Block(ctx context.Context, blockerID int64, blockedID int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	// check target user
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1", blockedID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO blocks (blocker_id, blocked_id, created_at) VALUES ($1, $2, $3) ON CONFLICT (blocker_id, blocked_id) DO NOTHING",
+		blockerID, blockedID, time.Now().UnixMilli())
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	var accepted int
+	err = tx.QueryRowContext(ctx, `
+		WITH removed AS (
+			DELETE FROM friendships
+			WHERE (user_id = $1 AND added_by = $2) OR (user_id = $2 AND added_by = $1)
+			RETURNING status
+		)
+		SELECT COUNT(*) FROM removed WHERE status = $3`,
+		blockerID, blockedID, entity.FriendshipStatusAccepted).Scan(&accepted)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if accepted > 0 {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET friend_count = friend_count - 1 WHERE id = $1 OR id = $2", blockerID, blockedID)
+		if err != nil {
+			return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *BlockRepositoryImpl) Unblock(ctx context.Context, blockerID int64, blockedID int64) (int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2", blockerID, blockedID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *BlockRepositoryImpl) IsBlocked(ctx context.Context, user1 int64, user2 int64) (bool, int, error) {
+	blocked, err := isBlocked(ctx, r.db, user1, user2)
+	if err != nil {
+		return false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return blocked, http.StatusOK, nil
+}
+
+// FindAll list the users blocked by the user, most recent first
+func (r *BlockRepositoryImpl) FindAll(ctx context.Context, filter entity.FindAllBlockRequest) ([]entity.Block, *common.Meta, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM blocks WHERE blocker_id = $1", filter.UserID).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			b.blocker_id,
+			b.blocked_id,
+			b.created_at,
+			u.id,
+			u.name,
+			u.image_url,
+			u.friend_count,
+			u.created_at
+		FROM blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC, b.blocked_id DESC
+		LIMIT $2 OFFSET $3`, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	blocks := []entity.Block{}
+	for rows.Next() {
+		b := entity.Block{}
+		err = rows.Scan(&b.BlockerID, &b.BlockedID, &b.CreatedAt, &b.User.ID, &b.User.Name, &b.User.ImageUrl, &b.User.FriendCount, &b.User.CreatedAt)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return blocks, &meta, http.StatusOK, nil
+}
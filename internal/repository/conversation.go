@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type ConversationRepository interface {
+	FindOrCreate(ctx context.Context, userID int64, friendID int64) (*entity.Conversation, int, error)
+	FindByID(ctx context.Context, id int64) (*entity.Conversation, int, error)
+	FindAll(ctx context.Context, filter entity.FindAllConversationRequest) ([]entity.Conversation, *common.Meta, int, error)
+	CreateMessage(ctx context.Context, ent entity.Message) (*entity.Message, int, error)
+	FindMessages(ctx context.Context, filter entity.FindAllMessageRequest) ([]entity.Message, int, error)
+	MarkRead(ctx context.Context, conversationID int64, userID int64) (int64, int, error)
+}
+
+func NewConversationRepository(logger zerolog.Logger, db *sql.DB) ConversationRepository {
+	return &ConversationRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type ConversationRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+// FindOrCreate return the conversation between the two users, starting it when they are friends
+func (r *ConversationRepositoryImpl) FindOrCreate(ctx context.Context, userID int64, friendID int64) (*entity.Conversation, int, error) {
+	user1, user2 := userID, friendID
+	if user1 > user2 {
+		user1, user2 = user2, user1
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	friend, err := isFriend(ctx, tx, userID, friendID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	if !friend {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "user is not friend")
+	}
+
+	now := time.Now().UnixMilli()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO conversations (user1_id, user2_id, last_message_at, created_at, updated_at) VALUES ($1, $2, $3, $3, $3)
+		ON CONFLICT (user1_id, user2_id) DO NOTHING`,
+		user1, user2, now)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	conv := entity.Conversation{}
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, user1_id, user2_id, last_message_at, created_at, updated_at FROM conversations WHERE user1_id = $1 AND user2_id = $2",
+		user1, user2).Scan(&conv.ID, &conv.User1ID, &conv.User2ID, &conv.LastMessageAt, &conv.CreatedAt, &conv.UpdatedAt)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &conv, http.StatusOK, nil
+}
+
+func (r *ConversationRepositoryImpl) FindByID(ctx context.Context, id int64) (*entity.Conversation, int, error) {
+	conv := entity.Conversation{}
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, user1_id, user2_id, last_message_at, created_at, updated_at FROM conversations WHERE id = $1",
+		id).Scan(&conv.ID, &conv.User1ID, &conv.User2ID, &conv.LastMessageAt, &conv.CreatedAt, &conv.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &conv, http.StatusOK, nil
+}
+
+// FindAll list the conversations of the user, most recently active first, with the latest message
+// and the number of unread messages sent by the other participant
+func (r *ConversationRepositoryImpl) FindAll(ctx context.Context, filter entity.FindAllConversationRequest) ([]entity.Conversation, *common.Meta, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM conversations WHERE user1_id = $1 OR user2_id = $1",
+		filter.UserID).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			c.id,
+			c.user1_id,
+			c.user2_id,
+			c.last_message_at,
+			c.created_at,
+			c.updated_at,
+			u.id,
+			u.name,
+			u.image_url,
+			m.id,
+			m.sender_id,
+			m.content,
+			m.read_at,
+			m.created_at,
+			(SELECT COUNT(*) FROM messages um WHERE um.conversation_id = c.id AND um.sender_id <> $1 AND um.read_at IS NULL)
+		FROM conversations c
+		JOIN users u ON u.id = CASE WHEN c.user1_id = $1 THEN c.user2_id ELSE c.user1_id END
+		LEFT JOIN LATERAL (
+			SELECT id, sender_id, content, read_at, created_at FROM messages
+			WHERE conversation_id = c.id
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) m ON true
+		WHERE c.user1_id = $1 OR c.user2_id = $1
+		ORDER BY c.last_message_at DESC, c.id DESC
+		LIMIT $2 OFFSET $3`, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	conversations := []entity.Conversation{}
+	for rows.Next() {
+		conv := entity.Conversation{}
+		var (
+			messageID        sql.NullInt64
+			messageSenderID  sql.NullInt64
+			messageContent   sql.NullString
+			messageReadAt    *int64
+			messageCreatedAt sql.NullInt64
+		)
+		err = rows.Scan(
+			&conv.ID, &conv.User1ID, &conv.User2ID, &conv.LastMessageAt, &conv.CreatedAt, &conv.UpdatedAt,
+			&conv.Friend.ID, &conv.Friend.Name, &conv.Friend.ImageUrl,
+			&messageID, &messageSenderID, &messageContent, &messageReadAt, &messageCreatedAt,
+			&conv.UnreadCount,
+		)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+
+		if messageID.Valid {
+			conv.LastMessage = &entity.Message{
+				ID:             messageID.Int64,
+				ConversationID: conv.ID,
+				SenderID:       messageSenderID.Int64,
+				Content:        messageContent.String,
+				ReadAt:         messageReadAt,
+				CreatedAt:      messageCreatedAt.Int64,
+			}
+		}
+		conversations = append(conversations, conv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return conversations, &meta, http.StatusOK, nil
+}
+
+// CreateMessage insert the message and move the conversation to the top of both inboxes
+func (r *ConversationRepositoryImpl) CreateMessage(ctx context.Context, ent entity.Message) (*entity.Message, int, error) {
+	ent.CreatedAt = time.Now().UnixMilli()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO messages (conversation_id, sender_id, content, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		ent.ConversationID, ent.SenderID, ent.Content, ent.CreatedAt).Scan(&ent.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE conversations SET last_message_at = $1, updated_at = $1 WHERE id = $2",
+		ent.CreatedAt, ent.ConversationID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &ent, http.StatusOK, nil
+}
+
+// FindMessages list messages of a conversation newest first, starting after the cursor when it is set
+func (r *ConversationRepositoryImpl) FindMessages(ctx context.Context, filter entity.FindAllMessageRequest) ([]entity.Message, int, error) {
+	query := "SELECT id, conversation_id, sender_id, content, read_at, created_at FROM messages WHERE conversation_id = $1"
+	args := []interface{}{filter.ConversationID, filter.Limit}
+	if filter.CursorID != 0 {
+		query += " AND (created_at, id) < ($3, $4)"
+		args = append(args, filter.CursorCreatedAt, filter.CursorID)
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT $2"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	messages := []entity.Message{}
+	for rows.Next() {
+		m := entity.Message{}
+		err = rows.Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.Content, &m.ReadAt, &m.CreatedAt)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	return messages, http.StatusOK, nil
+}
+
+// MarkRead mark every message the other participant sent in the conversation as read by userID
+// and return how many were updated
+func (r *ConversationRepositoryImpl) MarkRead(ctx context.Context, conversationID int64, userID int64) (int64, int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE messages SET read_at = $1 WHERE conversation_id = $2 AND sender_id <> $3 AND read_at IS NULL",
+		time.Now().UnixMilli(), conversationID, userID)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return row, http.StatusOK, nil
+}
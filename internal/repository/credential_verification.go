@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type CredentialVerificationRepository interface {
+	Create(ctx context.Context, verification entity.CredentialVerification) (*entity.CredentialVerification, int, error)
+	Register(ctx context.Context, user entity.User, verification entity.CredentialVerification) (*entity.User, int, error)
+	FindPending(ctx context.Context, credentialType string, credentialValue string) (*entity.CredentialVerification, int, error)
+	Renew(ctx context.Context, id int64, codeHash string, expiresAt int64, sentBefore int64, maxSends int) (int, error)
+	Confirm(ctx context.Context, credentialType string, credentialValue string, codeHash string, maxAttempts int) (int64, int, error)
+}
+
+func NewCredentialVerificationRepository(logger zerolog.Logger, db *sql.DB) CredentialVerificationRepository {
+	return &CredentialVerificationRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type CredentialVerificationRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+// credentialColumn return the users column holding a credential type
+func credentialColumn(credentialType string) (string, bool) {
+	switch credentialType {
+	case entity.CredentialTypeEmail:
+		return "email", true
+	case entity.CredentialTypePhone:
+		return "phone", true
+	}
+	return "", false
+}
+
+// insertVerification replace the pending credential of the same type of the user. It fail with a conflict
+// when another user has the same credential pending and not yet expired. An expired registration
+// of the credential is removed with its user, who never had a credential to log in with.
+func insertVerification(ctx context.Context, tx *sql.Tx, verification *entity.CredentialVerification) (int, error) {
+	now := time.Now().UnixMilli()
+	verification.CreatedAt = now
+	verification.SentAt = now
+	verification.SendCount = 1
+
+	_, err := tx.ExecContext(ctx,
+		`WITH removed AS (
+			DELETE FROM credential_verifications
+			WHERE (user_id = $1 AND credential_type = $2)
+			OR (credential_type = $2 AND credential_value = $3 AND expires_at <= $4)
+			RETURNING user_id
+		)
+		DELETE FROM users
+		WHERE id IN (SELECT user_id FROM removed) AND id <> $1 AND email = '' AND phone = ''`,
+		verification.UserID, verification.CredentialType, verification.CredentialValue, now)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO credential_verifications (user_id, credential_type, credential_value, code_hash, expires_at, sent_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING RETURNING id`,
+		verification.UserID, verification.CredentialType, verification.CredentialValue, verification.CodeHash,
+		verification.ExpiresAt, verification.SentAt, verification.CreatedAt).Scan(&verification.ID)
+	if err != nil {
+		// the credential is pending for another user
+		if err == sql.ErrNoRows {
+			return http.StatusConflict, errors.Wrap(errorer.ErrPendingExist, errorer.ErrPendingExist.Error())
+		}
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusCreated, nil
+}
+
+// Create save the verification of a credential linked by an existing user
+func (r *CredentialVerificationRepositoryImpl) Create(ctx context.Context, verification entity.CredentialVerification) (*entity.CredentialVerification, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	code, err := insertVerification(ctx, tx, &verification)
+	if err != nil {
+		return nil, code, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &verification, code, nil
+}
+
+// Register insert a user without credential together with the verification of the credential
+// they registered with, so no user is left without a pending credential
+func (r *CredentialVerificationRepositoryImpl) Register(ctx context.Context, user entity.User, verification entity.CredentialVerification) (*entity.User, int, error) {
+	user.CreatedAt = time.Now().UnixMilli()
+	user.UpdatedAt = user.CreatedAt
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (email, phone, password, name, image_url, created_at, updated_at) VALUES ('', '', $1, $2, $3, $4, $5) RETURNING id",
+		user.Password, user.Name, &user.ImageUrl, user.CreatedAt, user.UpdatedAt).Scan(&user.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	verification.UserID = user.ID
+	code, err := insertVerification(ctx, tx, &verification)
+	if err != nil {
+		return nil, code, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &user, http.StatusCreated, nil
+}
+
+// FindPending return the unexpired verification of a credential
+func (r *CredentialVerificationRepositoryImpl) FindPending(ctx context.Context, credentialType string, credentialValue string) (*entity.CredentialVerification, int, error) {
+	var v entity.CredentialVerification
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, credential_type, credential_value, code_hash, attempts, send_count, expires_at, sent_at, created_at
+		FROM credential_verifications WHERE credential_type = $1 AND credential_value = $2 AND expires_at > $3`,
+		credentialType, credentialValue, time.Now().UnixMilli()).
+		Scan(&v.ID, &v.UserID, &v.CredentialType, &v.CredentialValue, &v.CodeHash, &v.Attempts, &v.SendCount, &v.ExpiresAt, &v.SentAt, &v.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &v, http.StatusOK, nil
+}
+
+// Renew replace the code of a verification and reset its attempts. It fail with too many requests
+// when the previous code was sent after sentBefore or the code was already sent maxSends times.
+func (r *CredentialVerificationRepositoryImpl) Renew(ctx context.Context, id int64, codeHash string, expiresAt int64, sentBefore int64, maxSends int) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE credential_verifications
+		SET code_hash = $1, expires_at = $2, attempts = 0, send_count = send_count + 1, sent_at = $3
+		WHERE id = $4 AND sent_at <= $5 AND send_count < $6`,
+		codeHash, expiresAt, time.Now().UnixMilli(), id, sentBefore, maxSends)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusTooManyRequests, errors.Wrap(errorer.ErrTooManyAttempts, errorer.ErrTooManyAttempts.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// Confirm check the code of a pending credential and set it on its user, it return the id of that user.
+// A wrong code use one attempt, the code stop working after maxAttempts wrong codes.
+func (r *CredentialVerificationRepositoryImpl) Confirm(ctx context.Context, credentialType string, credentialValue string, codeHash string, maxAttempts int) (int64, int, error) {
+	column, ok := credentialColumn(credentialType)
+	if !ok {
+		return 0, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+	now := time.Now().UnixMilli()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	var id, userID int64
+	var storedHash string
+	var attempts int
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, user_id, code_hash, attempts FROM credential_verifications
+		WHERE credential_type = $1 AND credential_value = $2 AND expires_at > $3 FOR UPDATE`,
+		credentialType, credentialValue, now).Scan(&id, &userID, &storedHash, &attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidCode, errorer.ErrInvalidCode.Error())
+		}
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if attempts >= maxAttempts {
+		return 0, http.StatusTooManyRequests, errors.Wrap(errorer.ErrTooManyAttempts, errorer.ErrTooManyAttempts.Error())
+	}
+
+	if subtle.ConstantTimeCompare([]byte(storedHash), []byte(codeHash)) != 1 {
+		_, err = tx.ExecContext(ctx, "UPDATE credential_verifications SET attempts = attempts + 1 WHERE id = $1", id)
+		if err != nil {
+			return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		return 0, http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidCode, errorer.ErrInvalidCode.Error())
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE "+column+" = $1)", credentialValue).Scan(&taken)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	if taken {
+		if credentialType == entity.CredentialTypeEmail {
+			return 0, http.StatusConflict, errors.Wrap(errorer.ErrEmailExist, errorer.ErrEmailExist.Error())
+		}
+		return 0, http.StatusConflict, errors.Wrap(errorer.ErrPhoneExist, errorer.ErrPhoneExist.Error())
+	}
+
+	// a credential can only be linked once, the user must start over to change it
+	res, err := tx.ExecContext(ctx,
+		"UPDATE users SET "+column+" = $1, updated_at = $2 WHERE id = $3 AND "+column+" = ''",
+		credentialValue, now, userID)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	if row == 0 {
+		return 0, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM credential_verifications WHERE id = $1", id)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return userID, http.StatusOK, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"socialapp/internal/model/entity"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// eventBufferSize is the number of recent events kept per user for resuming with a last event id
+	eventBufferSize = 100
+	// eventSubscriberBuffer is how many events may be queued for one connection before it is dropped
+	eventSubscriberBuffer = 32
+	// eventStreamRetention is how long the buffer of a user is kept after their last connection closed
+	eventStreamRetention = 5 * time.Minute
+)
+
+// EventRepository is an in-process broker delivering events to the open connections of a user
+type EventRepository interface {
+	// Publish deliver an event to every connection of the user. Users without a recent connection are skipped.
+	Publish(userID int64, eventType string, data interface{})
+	// Subscribe open a subscription for the user, returning the buffered events after lastEventID.
+	// reset is true when some of those events are no longer buffered.
+	Subscribe(userID int64, lastEventID int64) (sub *EventSubscription, missed []entity.Event, reset bool)
+	Unsubscribe(sub *EventSubscription)
+}
+
+type EventSubscription struct {
+	userID int64
+	// Events is closed when the subscription ends, including when the subscriber fell too far behind
+	Events <-chan entity.Event
+	events chan entity.Event
+}
+
+func NewEventRepository(logger zerolog.Logger) EventRepository {
+	return &EventRepositoryImpl{
+		logger:    logger,
+		streams:   map[int64]*eventStream{},
+		seq:       time.Now().UnixMicro(),
+		lastSweep: time.Now(),
+	}
+}
+
+type EventRepositoryImpl struct {
+	logger    zerolog.Logger
+	mu        sync.Mutex
+	streams   map[int64]*eventStream
+	seq       int64
+	lastSweep time.Time
+}
+
+type eventStream struct {
+	subs map[*EventSubscription]struct{}
+	// buffer is a ring of the latest events, next is where the following event is written
+	buffer []entity.Event
+	next   int
+	// since is the id after which the buffer holds every event of the user
+	since     int64
+	idleSince time.Time
+}
+
+func (r *EventRepositoryImpl) Publish(userID int64, eventType string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep()
+	stream, ok := r.streams[userID]
+	if !ok {
+		return
+	}
+
+	r.seq++
+	ev := entity.Event{
+		ID:        r.seq,
+		UserID:    userID,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+
+	if len(stream.buffer) < eventBufferSize {
+		stream.buffer = append(stream.buffer, ev)
+	} else {
+		stream.since = stream.buffer[stream.next].ID
+		stream.buffer[stream.next] = ev
+		stream.next = (stream.next + 1) % eventBufferSize
+	}
+
+	for sub := range stream.subs {
+		select {
+		case sub.events <- ev:
+		default:
+			// the connection cannot keep up, drop it so the client reconnects and resumes from the buffer
+			r.logger.Warn().Int64("userID", userID).Msg("dropping slow event subscriber")
+			r.remove(stream, sub)
+		}
+	}
+}
+
+func (r *EventRepositoryImpl) Subscribe(userID int64, lastEventID int64) (*EventSubscription, []entity.Event, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep()
+	stream, ok := r.streams[userID]
+	if !ok {
+		stream = &eventStream{
+			subs:  map[*EventSubscription]struct{}{},
+			since: r.seq,
+		}
+		r.streams[userID] = stream
+	}
+
+	events := make(chan entity.Event, eventSubscriberBuffer)
+	sub := &EventSubscription{userID: userID, Events: events, events: events}
+	stream.subs[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, false
+	}
+
+	missed := []entity.Event{}
+	for i := 0; i < len(stream.buffer); i++ {
+		ev := stream.buffer[(stream.next+i)%len(stream.buffer)]
+		if ev.ID > lastEventID {
+			missed = append(missed, ev)
+		}
+	}
+	return sub, missed, lastEventID < stream.since
+}
+
+func (r *EventRepositoryImpl) Unsubscribe(sub *EventSubscription) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stream, ok := r.streams[sub.userID]
+	if !ok {
+		return
+	}
+	r.remove(stream, sub)
+}
+
+func (r *EventRepositoryImpl) remove(stream *eventStream, sub *EventSubscription) {
+	if _, ok := stream.subs[sub]; !ok {
+		return
+	}
+	delete(stream.subs, sub)
+	close(sub.events)
+	if len(stream.subs) == 0 {
+		stream.idleSince = time.Now()
+	}
+}
+
+// sweep forget the buffers of users that have not been connected for eventStreamRetention
+func (r *EventRepositoryImpl) sweep() {
+	if time.Since(r.lastSweep) < eventStreamRetention {
+		return
+	}
+	r.lastSweep = time.Now()
+
+	for userID, stream := range r.streams {
+		if len(stream.subs) == 0 && time.Since(stream.idleSince) > eventStreamRetention {
+			delete(r.streams, userID)
+		}
+	}
+}
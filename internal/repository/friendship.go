@@ -11,14 +11,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
 type FriendshipRepository interface {
-	CreateFriendship(ctx context.Context, userID int64, addedBy int64) (int, error)
+	CreateFriendship(ctx context.Context, userID int64, addedBy int64) (*entity.Friendship, int, error)
 	DeleteFriendship(ctx context.Context, friend1 int64, friend2 int64) (int, error)
 	FindAll(ctx context.Context, filter entity.FindAllFriendshipRequest) ([]entity.User, *common.Meta, int, error)
+	AcceptFriendship(ctx context.Context, id int64, userID int64) (*entity.Friendship, int, error)
+	RejectFriendship(ctx context.Context, id int64, userID int64) (int, error)
+	CancelFriendship(ctx context.Context, id int64, userID int64) (int, error)
+	FindRequests(ctx context.Context, filter entity.FindAllFriendRequestRequest) ([]entity.FriendRequest, *common.Meta, int, error)
+	FindFriendIDs(ctx context.Context, userID int64) ([]int64, int, error)
+	IsFriend(ctx context.Context, user1 int64, user2 int64) (bool, int, error)
+	FindMutual(ctx context.Context, filter entity.FindMutualFriendRequest) ([]entity.User, *common.Meta, int, error)
+	FindSuggestions(ctx context.Context, filter entity.FindFriendSuggestionRequest) ([]entity.FriendSuggestion, *common.Meta, int, error)
 }
 
 func NewFriendshipRepository(logger zerolog.Logger, db *sql.DB) FriendshipRepository {
@@ -33,49 +42,241 @@ type FriendshipRepositoryImpl struct {
 	db     *sql.DB
 }
 
-func (r *FriendshipRepositoryImpl) CreateFriendship(ctx context.Context, userID int64, addedBy int64) (int, error) {
+// friendIDsQuery select the id of every accepted friend of the user bound to placeholder,
+// each direction is a separate branch so both friendship indexes can be used
+func friendIDsQuery(placeholder string) string {
+	return `SELECT f.added_by AS id FROM friendships f WHERE f.user_id = ` + placeholder + ` AND f.status = '` + entity.FriendshipStatusAccepted + `'
+		UNION ALL
+		SELECT f.user_id AS id FROM friendships f WHERE f.added_by = ` + placeholder + ` AND f.status = '` + entity.FriendshipStatusAccepted + `'`
+}
+
+// suggestionFriendSample is the number of most recent friends whose friends are considered
+// for suggestions, it bounds the cost of the query for users with large friend lists
+const suggestionFriendSample = 200
+
+// isUniqueViolation report whether err is a postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// rowQueryer is implemented by both *sql.DB and *sql.Tx
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// isFriend report whether the two users have an accepted friendship, in either direction
+func isFriend(ctx context.Context, q rowQueryer, user1 int64, user2 int64) (bool, error) {
+	var id int64
+	err := q.QueryRowContext(
+		ctx,
+		"SELECT id FROM friendships WHERE ((user_id = $1 AND added_by = $2) OR (user_id = $2 AND added_by = $1)) AND status = $3",
+		user1, user2, entity.FriendshipStatusAccepted,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *FriendshipRepositoryImpl) CreateFriendship(ctx context.Context, userID int64, addedBy int64) (*entity.Friendship, int, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 	defer tx.Rollback()
 
+	// check target user
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1", userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	blocked, err := isBlocked(ctx, tx, userID, addedBy)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	if blocked {
+		return nil, http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, "user is blocked")
+	}
+
 	// check friendship
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM friendships WHERE (user_id = $1 AND added_by = $2) OR (user_id = $2 AND added_by = $1)",
+		userID, addedBy).Scan(&status)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if status == entity.FriendshipStatusAccepted {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrAlreadyFriend, errorer.ErrAlreadyFriend.Error())
+	}
+
+	if status == entity.FriendshipStatusPending {
+		return nil, http.StatusConflict, errors.Wrap(errorer.ErrRequestExist, errorer.ErrRequestExist.Error())
+	}
+
 	frd := entity.Friendship{
 		UserID:    userID,
 		AddedBy:   addedBy,
+		Status:    entity.FriendshipStatusPending,
 		CreatedAt: time.Now().UnixMilli(),
 		UpdatedAt: time.Now().UnixMilli(),
 	}
 	err = tx.QueryRowContext(ctx,
-		"INSERT INTO friendships (user_id, added_by, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id",
-		frd.UserID, frd.AddedBy, frd.CreatedAt, frd.UpdatedAt).Scan(&frd.ID)
+		"INSERT INTO friendships (user_id, added_by, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		frd.UserID, frd.AddedBy, frd.Status, frd.CreatedAt, frd.UpdatedAt).Scan(&frd.ID)
 
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		// a concurrent request between the same users was inserted after the check above
+		if isUniqueViolation(err) {
+			return nil, http.StatusConflict, errors.Wrap(errorer.ErrRequestExist, errorer.ErrRequestExist.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &frd, http.StatusOK, nil
+}
+
+// AcceptFriendship accept a pending request sent to userID and update friend count of both users
+func (r *FriendshipRepositoryImpl) AcceptFriendship(ctx context.Context, id int64, userID int64) (*entity.Friendship, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	frd := entity.Friendship{ID: id, UserID: userID, Status: entity.FriendshipStatusAccepted}
+	err = tx.QueryRowContext(ctx,
+		"UPDATE friendships SET status = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND status = $5 RETURNING added_by, created_at, updated_at",
+		entity.FriendshipStatusAccepted, time.Now().UnixMilli(), id, userID, entity.FriendshipStatusPending).Scan(&frd.AddedBy, &frd.CreatedAt, &frd.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 
 	res, err := tx.ExecContext(ctx, "UPDATE users SET friend_count = friend_count + 1 WHERE id = $1 OR id = $2", frd.UserID, frd.AddedBy)
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 
 	row, err := res.RowsAffected()
 
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 
 	if row < 1 {
-		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
 	}
 
 	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &frd, http.StatusOK, nil
+}
+
+// RejectFriendship remove a pending request sent to userID
+func (r *FriendshipRepositoryImpl) RejectFriendship(ctx context.Context, id int64, userID int64) (int, error) {
+	return r.deletePendingRequest(ctx, "DELETE FROM friendships WHERE id = $1 AND user_id = $2 AND status = $3", id, userID)
+}
+
+// CancelFriendship remove a pending request sent by userID
+func (r *FriendshipRepositoryImpl) CancelFriendship(ctx context.Context, id int64, userID int64) (int, error) {
+	return r.deletePendingRequest(ctx, "DELETE FROM friendships WHERE id = $1 AND added_by = $2 AND status = $3", id, userID)
+}
+
+func (r *FriendshipRepositoryImpl) deletePendingRequest(ctx context.Context, query string, id int64, userID int64) (int, error) {
+	res, err := r.db.ExecContext(ctx, query, id, userID, entity.FriendshipStatusPending)
+	if err != nil {
 		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
+
+	row, err := res.RowsAffected()
+
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
 	return http.StatusOK, nil
 }
 
+// FindRequests list pending requests sent to (incoming) or sent by (outgoing) the user
+func (r *FriendshipRepositoryImpl) FindRequests(ctx context.Context, filter entity.FindAllFriendRequestRequest) ([]entity.FriendRequest, *common.Meta, int, error) {
+	ownerColumn, otherColumn := "f.added_by", "f.user_id"
+	if filter.Incoming {
+		ownerColumn, otherColumn = "f.user_id", "f.added_by"
+	}
+
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM friendships f WHERE "+ownerColumn+" = $1 AND f.status = $2",
+		filter.UserID, entity.FriendshipStatusPending).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	query := `
+		SELECT
+			f.id,
+			f.created_at,
+			u.id,
+			u.name,
+			u.image_url,
+			u.friend_count,
+			u.created_at
+		FROM friendships f
+		JOIN users u ON u.id = ` + otherColumn + `
+		WHERE ` + ownerColumn + ` = $1 AND f.status = $2
+		ORDER BY f.created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.UserID, entity.FriendshipStatusPending, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	requests := []entity.FriendRequest{}
+	for rows.Next() {
+		req := entity.FriendRequest{}
+		err = rows.Scan(&req.ID, &req.CreatedAt, &req.User.ID, &req.User.Name, &req.User.ImageUrl, &req.User.FriendCount, &req.User.CreatedAt)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return requests, &meta, http.StatusOK, nil
+}
+
 func (r *FriendshipRepositoryImpl) DeleteFriendship(ctx context.Context, friend1 int64, friend2 int64) (int, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -84,7 +285,7 @@ func (r *FriendshipRepositoryImpl) DeleteFriendship(ctx context.Context, friend1
 	defer tx.Rollback()
 
 	// Delete friendship
-	res, err := tx.ExecContext(ctx, "DELETE FROM friendships WHERE (user_id = $1 AND added_by = $2) OR (user_id = $2 AND added_by = $1)", friend1, friend2)
+	res, err := tx.ExecContext(ctx, "DELETE FROM friendships WHERE ((user_id = $1 AND added_by = $2) OR (user_id = $2 AND added_by = $1)) AND status = $3", friend1, friend2, entity.FriendshipStatusAccepted)
 
 	if err != nil {
 		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
@@ -137,6 +338,15 @@ func (r *FriendshipRepositoryImpl) FindAll(ctx context.Context, filter entity.Fi
 		conditions = append(conditions, "(f.user_id = $"+fmt.Sprint(argIndex)+" OR f.added_by = $"+fmt.Sprint(argIndex)+")")
 		args = append(args, filter.UserID)
 		argIndex++
+		conditions = append(conditions, "f.status = $"+fmt.Sprint(argIndex))
+		args = append(args, entity.FriendshipStatusAccepted)
+		argIndex++
+	}
+
+	if filter.UserID != 0 {
+		conditions = append(conditions, "u.id NOT IN ("+blockedIDsQuery("$"+fmt.Sprint(argIndex))+")")
+		args = append(args, filter.UserID)
+		argIndex++
 	}
 
 	// Construct the WHERE clause
@@ -201,6 +411,9 @@ func (r *FriendshipRepositoryImpl) FindAll(ctx context.Context, filter entity.Fi
 		}
 		users = append(users, user)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
 
 	meta := common.Meta{
 		Total:  len(users),
@@ -210,3 +423,146 @@ func (r *FriendshipRepositoryImpl) FindAll(ctx context.Context, filter entity.Fi
 
 	return users, &meta, http.StatusOK, nil
 }
+
+// FindFriendIDs list the id of every accepted friend of the user
+func (r *FriendshipRepositoryImpl) FindFriendIDs(ctx context.Context, userID int64) ([]int64, int, error) {
+	rows, err := r.db.QueryContext(ctx, friendIDsQuery("$1"), userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return ids, http.StatusOK, nil
+}
+
+func (r *FriendshipRepositoryImpl) IsFriend(ctx context.Context, user1 int64, user2 int64) (bool, int, error) {
+	friend, err := isFriend(ctx, r.db, user1, user2)
+	if err != nil {
+		return false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return friend, http.StatusOK, nil
+}
+
+// FindMutual list the friends both users have in common, ordered by name
+func (r *FriendshipRepositoryImpl) FindMutual(ctx context.Context, filter entity.FindMutualFriendRequest) ([]entity.User, *common.Meta, int, error) {
+	mutualQuery := `(` + friendIDsQuery("$1") + `) INTERSECT (` + friendIDsQuery("$2") + `)`
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+mutualQuery+") mutual", filter.UserID, filter.OtherID).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			u.id,
+			u.name,
+			u.image_url,
+			u.friend_count,
+			u.created_at
+		FROM users u
+		WHERE u.id IN (`+mutualQuery+`)
+		ORDER BY u.name, u.id
+		LIMIT $3 OFFSET $4`, filter.UserID, filter.OtherID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		user := entity.User{}
+		err = rows.Scan(&user.ID, &user.Name, &user.ImageUrl, &user.FriendCount, &user.CreatedAt)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return users, &meta, http.StatusOK, nil
+}
+
+// FindSuggestions rank friends of the user's friends by the number of friends they have in common
+// with the user, leaving out blocked users. Only the most recent friends are walked, see suggestionFriendSample.
+func (r *FriendshipRepositoryImpl) FindSuggestions(ctx context.Context, filter entity.FindFriendSuggestionRequest) ([]entity.FriendSuggestion, *common.Meta, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH friends AS (
+			SELECT mine.id FROM (
+				SELECT f.added_by AS id, f.updated_at FROM friendships f WHERE f.user_id = $1 AND f.status = $2
+				UNION ALL
+				SELECT f.user_id AS id, f.updated_at FROM friendships f WHERE f.added_by = $1 AND f.status = $2
+			) mine
+			ORDER BY mine.updated_at DESC
+			LIMIT $3
+		), candidates AS (
+			SELECT fof.id, COUNT(*) AS mutual_count
+			FROM friends
+			CROSS JOIN LATERAL (`+friendIDsQuery("friends.id")+`) fof
+			WHERE fof.id <> $1
+			GROUP BY fof.id
+		)
+		SELECT
+			u.id,
+			u.name,
+			u.image_url,
+			u.friend_count,
+			u.created_at,
+			c.mutual_count,
+			COUNT(*) OVER ()
+		FROM candidates c
+		JOIN users u ON u.id = c.id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM friendships f
+			WHERE (f.user_id = c.id AND f.added_by = $1) OR (f.user_id = $1 AND f.added_by = c.id)
+		)
+		AND c.id NOT IN (`+blockedIDsQuery("$1")+`)
+		ORDER BY c.mutual_count DESC, u.friend_count DESC, u.id
+		LIMIT $4 OFFSET $5`,
+		filter.UserID, entity.FriendshipStatusAccepted, suggestionFriendSample, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	var total int
+	suggestions := []entity.FriendSuggestion{}
+	for rows.Next() {
+		s := entity.FriendSuggestion{}
+		err = rows.Scan(&s.ID, &s.Name, &s.ImageUrl, &s.FriendCount, &s.CreatedAt, &s.MutualFriendCount, &total)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return suggestions, &meta, http.StatusOK, nil
+}
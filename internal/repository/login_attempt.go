@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// LoginAttemptDriverMemory keep attempts in the process, for a single instance
+	LoginAttemptDriverMemory = "memory"
+	// LoginAttemptDriverPostgres share attempts between instances
+	LoginAttemptDriverPostgres = "postgres"
+)
+
+// LoginAttemptRepository count the failed logins of a key, such as a credential or an ip
+type LoginAttemptRepository interface {
+	// Reserve count a login attempt on key as a failure before it is checked, unless the key is blocked,
+	// in one atomic step so concurrent attempts cannot all pass the check. Failures older than window
+	// are forgotten first and the key is blocked for the duration backoff return for the new failure count.
+	// It report false when the key is blocked, with the attempts as they were before the refusal
+	// was saved in ThrottledUntil.
+	Reserve(ctx context.Context, key string, window time.Duration, backoff func(failures int) time.Duration) (*entity.LoginAttempt, bool, int, error)
+	// Release take back a reserved attempt that did not fail
+	Release(ctx context.Context, key string, backoff func(failures int) time.Duration) (int, error)
+	Reset(ctx context.Context, key string) (int, error)
+	// DeleteExpired remove the keys without failure since before and no longer blocked
+	DeleteExpired(ctx context.Context, before int64) (int64, int, error)
+}
+
+func NewLoginAttemptRepository(logger zerolog.Logger, driver string, db *sql.DB) (LoginAttemptRepository, error) {
+	switch driver {
+	case LoginAttemptDriverMemory:
+		return NewMemoryLoginAttemptRepository(logger), nil
+	case LoginAttemptDriverPostgres:
+		return NewPostgresLoginAttemptRepository(logger, db), nil
+	}
+	return nil, fmt.Errorf("unknown login attempt driver %q", driver)
+}
+
+// releasedLoginAttempt remove a reserved failure from the attempts of a key, the block becomes
+// the one the remaining failures cause from the last attempt
+func releasedLoginAttempt(attempt entity.LoginAttempt, backoff func(failures int) time.Duration) entity.LoginAttempt {
+	if attempt.Failures > 0 {
+		attempt.Failures--
+	}
+
+	attempt.BlockedUntil = 0
+	if delay := backoff(attempt.Failures); delay > 0 {
+		attempt.BlockedUntil = attempt.LastFailureAt + delay.Milliseconds()
+	}
+	return attempt
+}
+
+// nextLoginAttempt apply a failure at now to the current attempts of a key
+func nextLoginAttempt(attempt entity.LoginAttempt, now time.Time, window time.Duration, backoff func(failures int) time.Duration) entity.LoginAttempt {
+	if attempt.LastFailureAt <= now.Add(-window).UnixMilli() {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now.UnixMilli()
+
+	if delay := backoff(attempt.Failures); delay > 0 {
+		attempt.BlockedUntil = now.Add(delay).UnixMilli()
+	}
+	return attempt
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/model/entity"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// NewMemoryLoginAttemptRepository keep attempts in memory, each instance count its own
+func NewMemoryLoginAttemptRepository(logger zerolog.Logger) *MemoryLoginAttemptRepositoryImpl {
+	return &MemoryLoginAttemptRepositoryImpl{
+		logger:   logger,
+		attempts: map[string]entity.LoginAttempt{},
+	}
+}
+
+type MemoryLoginAttemptRepositoryImpl struct {
+	logger   zerolog.Logger
+	mu       sync.Mutex
+	attempts map[string]entity.LoginAttempt
+}
+
+func (r *MemoryLoginAttemptRepositoryImpl) Reserve(ctx context.Context, key string, window time.Duration, backoff func(failures int) time.Duration) (*entity.LoginAttempt, bool, int, error) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := r.attempts[key]
+	attempt.Key = key
+	if attempt.BlockedUntil > now.UnixMilli() {
+		refused := attempt
+		refused.ThrottledUntil = attempt.BlockedUntil
+		r.attempts[key] = refused
+		return &attempt, false, http.StatusOK, nil
+	}
+
+	attempt = nextLoginAttempt(attempt, now, window, backoff)
+	r.attempts[key] = attempt
+	return &attempt, true, http.StatusOK, nil
+}
+
+func (r *MemoryLoginAttemptRepositoryImpl) Release(ctx context.Context, key string, backoff func(failures int) time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return http.StatusOK, nil
+	}
+	r.attempts[key] = releasedLoginAttempt(attempt, backoff)
+	return http.StatusOK, nil
+}
+
+func (r *MemoryLoginAttemptRepositoryImpl) Reset(ctx context.Context, key string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return http.StatusOK, nil
+}
+
+func (r *MemoryLoginAttemptRepositoryImpl) DeleteExpired(ctx context.Context, before int64) (int64, int, error) {
+	now := time.Now().UnixMilli()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, attempt := range r.attempts {
+		if attempt.LastFailureAt < before && attempt.BlockedUntil <= now {
+			delete(r.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, http.StatusOK, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func NewPostgresLoginAttemptRepository(logger zerolog.Logger, db *sql.DB) *PostgresLoginAttemptRepositoryImpl {
+	return &PostgresLoginAttemptRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type PostgresLoginAttemptRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+// lockAttempt lock the row of key until tx ends, creating it when missing
+func lockAttempt(ctx context.Context, tx *sql.Tx, key string) (entity.LoginAttempt, error) {
+	attempt := entity.LoginAttempt{Key: key}
+
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 0, 0) ON CONFLICT (key) DO NOTHING",
+		key)
+	if err != nil {
+		return attempt, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT failures, last_failure_at, blocked_until, throttled_until FROM login_attempts WHERE key = $1 FOR UPDATE",
+		key).Scan(&attempt.Failures, &attempt.LastFailureAt, &attempt.BlockedUntil, &attempt.ThrottledUntil)
+	return attempt, err
+}
+
+func (r *PostgresLoginAttemptRepositoryImpl) Reserve(ctx context.Context, key string, window time.Duration, backoff func(failures int) time.Duration) (*entity.LoginAttempt, bool, int, error) {
+	now := time.Now()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	current, err := lockAttempt(ctx, tx, key)
+	if err != nil {
+		return nil, false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if current.BlockedUntil > now.UnixMilli() {
+		if current.ThrottledUntil != current.BlockedUntil {
+			_, err = tx.ExecContext(ctx, "UPDATE login_attempts SET throttled_until = blocked_until WHERE key = $1", key)
+			if err != nil {
+				return nil, false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+			}
+		}
+		return &current, false, http.StatusOK, nil
+	}
+
+	attempt := nextLoginAttempt(current, now, window, backoff)
+	_, err = tx.ExecContext(ctx,
+		"UPDATE login_attempts SET failures = $1, last_failure_at = $2, blocked_until = $3 WHERE key = $4",
+		attempt.Failures, attempt.LastFailureAt, attempt.BlockedUntil, key)
+	if err != nil {
+		return nil, false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &attempt, true, http.StatusOK, nil
+}
+
+func (r *PostgresLoginAttemptRepositoryImpl) Release(ctx context.Context, key string, backoff func(failures int) time.Duration) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	current, err := lockAttempt(ctx, tx, key)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	attempt := releasedLoginAttempt(current, backoff)
+	_, err = tx.ExecContext(ctx,
+		"UPDATE login_attempts SET failures = $1, blocked_until = $2 WHERE key = $3",
+		attempt.Failures, attempt.BlockedUntil, key)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *PostgresLoginAttemptRepositoryImpl) Reset(ctx context.Context, key string) (int, error) {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *PostgresLoginAttemptRepositoryImpl) DeleteExpired(ctx context.Context, before int64) (int64, int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM login_attempts WHERE last_failure_at < $1 AND blocked_until <= $2",
+		before, time.Now().UnixMilli())
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return deleted, http.StatusOK, nil
+}
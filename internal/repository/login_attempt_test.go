@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"socialapp/internal/model/entity"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// testBackoff block from the third failure for a second per failure, and lock out at the fifth
+func testBackoff(failures int) time.Duration {
+	switch {
+	case failures >= 5:
+		return time.Hour
+	case failures >= 3:
+		return time.Duration(failures) * time.Second
+	}
+	return 0
+}
+
+func TestNextLoginAttempt(t *testing.T) {
+	now := time.UnixMilli(1_000_000_000)
+	window := 15 * time.Minute
+
+	tests := []struct {
+		name    string
+		attempt entity.LoginAttempt
+		want    entity.LoginAttempt
+	}{
+		{
+			"first failure",
+			entity.LoginAttempt{},
+			entity.LoginAttempt{Failures: 1, LastFailureAt: now.UnixMilli()},
+		},
+		{
+			"free failure",
+			entity.LoginAttempt{Failures: 1, LastFailureAt: now.Add(-time.Minute).UnixMilli()},
+			entity.LoginAttempt{Failures: 2, LastFailureAt: now.UnixMilli()},
+		},
+		{
+			"failure starting the backoff",
+			entity.LoginAttempt{Failures: 2, LastFailureAt: now.Add(-time.Minute).UnixMilli()},
+			entity.LoginAttempt{Failures: 3, LastFailureAt: now.UnixMilli(), BlockedUntil: now.Add(3 * time.Second).UnixMilli()},
+		},
+		{
+			"failure reaching the lockout",
+			entity.LoginAttempt{Failures: 4, LastFailureAt: now.Add(-time.Minute).UnixMilli()},
+			entity.LoginAttempt{Failures: 5, LastFailureAt: now.UnixMilli(), BlockedUntil: now.Add(time.Hour).UnixMilli()},
+		},
+		{
+			"failures outside the window forgotten",
+			entity.LoginAttempt{Failures: 4, LastFailureAt: now.Add(-window).UnixMilli(), BlockedUntil: now.Add(-window).UnixMilli()},
+			entity.LoginAttempt{Failures: 1, LastFailureAt: now.UnixMilli(), BlockedUntil: now.Add(-window).UnixMilli()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextLoginAttempt(tt.attempt, now, window, testBackoff); got != tt.want {
+				t.Errorf("nextLoginAttempt = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleasedLoginAttempt(t *testing.T) {
+	last := int64(1_000_000_000)
+
+	tests := []struct {
+		name    string
+		attempt entity.LoginAttempt
+		want    entity.LoginAttempt
+	}{
+		{
+			"free failure released",
+			entity.LoginAttempt{Failures: 2, LastFailureAt: last},
+			entity.LoginAttempt{Failures: 1, LastFailureAt: last},
+		},
+		{
+			"block lifted under the backoff",
+			entity.LoginAttempt{Failures: 3, LastFailureAt: last, BlockedUntil: last + 3000},
+			entity.LoginAttempt{Failures: 2, LastFailureAt: last},
+		},
+		{
+			"lockout replaced by the previous delay",
+			entity.LoginAttempt{Failures: 5, LastFailureAt: last, BlockedUntil: last + time.Hour.Milliseconds()},
+			entity.LoginAttempt{Failures: 4, LastFailureAt: last, BlockedUntil: last + 4000},
+		},
+		{
+			"no failure left",
+			entity.LoginAttempt{},
+			entity.LoginAttempt{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releasedLoginAttempt(tt.attempt, testBackoff); got != tt.want {
+				t.Errorf("releasedLoginAttempt = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMemoryReserveConcurrent check concurrent attempts cannot pass the throttle together
+func TestMemoryReserveConcurrent(t *testing.T) {
+	repo := NewMemoryLoginAttemptRepository(zerolog.Nop())
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, _, err := repo.Reserve(context.Background(), "credential:email:a@b.c", time.Minute, testBackoff)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the third reservation start the backoff, every later one is refused
+	if allowed != 3 {
+		t.Errorf("allowed %d concurrent attempts, want 3", allowed)
+	}
+}
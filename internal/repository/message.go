@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"socialapp/internal/model/entity"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	MessageDriverLog  = "log"
+	MessageDriverFile = "file"
+)
+
+// MessageSender deliver messages to the email address or phone number of a user
+type MessageSender interface {
+	Send(ctx context.Context, msg entity.OutgoingMessage) (int, error)
+}
+
+type MessageConfig struct {
+	Driver string
+	// file driver
+	FilePath string
+}
+
+// NewMessageSender return the sender of cfg.Driver, the log and file drivers only record
+// messages and are meant for local development
+func NewMessageSender(logger zerolog.Logger, cfg MessageConfig) (MessageSender, error) {
+	switch cfg.Driver {
+	case MessageDriverLog:
+		return NewLogMessageSender(logger), nil
+	case MessageDriverFile:
+		return NewFileMessageSender(logger, cfg)
+	}
+	return nil, fmt.Errorf("unknown message driver %q", cfg.Driver)
+}
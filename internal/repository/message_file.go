@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// NewFileMessageSender append every message as a json line to cfg.FilePath instead of delivering it
+func NewFileMessageSender(logger zerolog.Logger, cfg MessageConfig) (MessageSender, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.FilePath), 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileMessageSenderImpl{
+		logger: logger,
+		path:   cfg.FilePath,
+	}, nil
+}
+
+type FileMessageSenderImpl struct {
+	logger zerolog.Logger
+	mu     sync.Mutex
+	path   string
+}
+
+type fileMessage struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	SentAt  string `json:"sentAt"`
+}
+
+func (s *FileMessageSenderImpl) Send(ctx context.Context, msg entity.OutgoingMessage) (int, error) {
+	line, err := json.Marshal(fileMessage{
+		Channel: msg.Channel,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+		SentAt:  time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/model/entity"
+
+	"github.com/rs/zerolog"
+)
+
+// NewLogMessageSender write messages to the application log instead of delivering them
+func NewLogMessageSender(logger zerolog.Logger) *LogMessageSenderImpl {
+	return &LogMessageSenderImpl{
+		logger: logger,
+	}
+}
+
+type LogMessageSenderImpl struct {
+	logger zerolog.Logger
+}
+
+func (s *LogMessageSenderImpl) Send(ctx context.Context, msg entity.OutgoingMessage) (int, error) {
+	s.logger.Info().
+		Str("channel", msg.Channel).
+		Str("to", msg.To).
+		Str("subject", msg.Subject).
+		Str("body", msg.Body).
+		Msg("message sent")
+	return http.StatusOK, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, ent entity.Notification) (*entity.Notification, int, error)
+	FindAll(ctx context.Context, filter entity.FindAllNotificationRequest) ([]entity.Notification, *common.Meta, int, error)
+	CountUnread(ctx context.Context, userID int64) (int64, int, error)
+	MarkRead(ctx context.Context, id int64, userID int64) (int, error)
+	MarkAllRead(ctx context.Context, userID int64) (int, error)
+	DeleteOlderThan(ctx context.Context, before int64) (int64, int, error)
+}
+
+func NewNotificationRepository(logger zerolog.Logger, db *sql.DB) NotificationRepository {
+	return &NotificationRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type NotificationRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+func (r *NotificationRepositoryImpl) Create(ctx context.Context, ent entity.Notification) (*entity.Notification, int, error) {
+	ent.CreatedAt = time.Now().UnixMilli()
+
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO notifications (user_id, actor_id, type, reference_id, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		ent.UserID, ent.ActorID, ent.Type, ent.ReferenceID, ent.CreatedAt).Scan(&ent.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	return &ent, http.StatusCreated, nil
+}
+
+func (r *NotificationRepositoryImpl) FindAll(ctx context.Context, filter entity.FindAllNotificationRequest) ([]entity.Notification, *common.Meta, int, error) {
+	whereClause := "WHERE n.user_id = $1"
+	if filter.UnreadOnly {
+		whereClause += " AND n.read_at IS NULL"
+	}
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications n "+whereClause, filter.UserID).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			n.id,
+			n.user_id,
+			n.actor_id,
+			n.type,
+			n.reference_id,
+			n.read_at,
+			n.created_at,
+			u.id,
+			u.name,
+			u.image_url
+		FROM notifications n
+		JOIN users u ON n.actor_id = u.id
+		`+whereClause+`
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $2 OFFSET $3`, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	notifications := []entity.Notification{}
+	for rows.Next() {
+		n := entity.Notification{}
+		err = rows.Scan(&n.ID, &n.UserID, &n.ActorID, &n.Type, &n.ReferenceID, &n.ReadAt, &n.CreatedAt, &n.Actor.ID, &n.Actor.Name, &n.Actor.ImageUrl)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return notifications, &meta, http.StatusOK, nil
+}
+
+func (r *NotificationRepositoryImpl) CountUnread(ctx context.Context, userID int64) (int64, int, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userID).Scan(&count)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return count, http.StatusOK, nil
+}
+
+func (r *NotificationRepositoryImpl) MarkRead(ctx context.Context, id int64, userID int64) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE notifications SET read_at = COALESCE(read_at, $1) WHERE id = $2 AND user_id = $3",
+		time.Now().UnixMilli(), id, userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *NotificationRepositoryImpl) MarkAllRead(ctx context.Context, userID int64) (int, error) {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL",
+		time.Now().UnixMilli(), userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// DeleteOlderThan remove notifications created before the given unix milli and return how many were removed
+func (r *NotificationRepositoryImpl) DeleteOlderThan(ctx context.Context, before int64) (int64, int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM notifications WHERE created_at < $1", before)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return row, http.StatusOK, nil
+}
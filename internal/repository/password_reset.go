@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset entity.PasswordReset) (*entity.PasswordReset, int, error)
+	Consume(ctx context.Context, tokenHash string) (int64, int, error)
+}
+
+func NewPasswordResetRepository(logger zerolog.Logger, db *sql.DB) PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type PasswordResetRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+// Create store a new reset token for the user, tokens requested before it stop working
+func (r *PasswordResetRepositoryImpl) Create(ctx context.Context, reset entity.PasswordReset) (*entity.PasswordReset, int, error) {
+	reset.CreatedAt = time.Now().UnixMilli()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE password_resets SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL",
+		reset.CreatedAt, reset.UserID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO password_resets (user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		reset.UserID, reset.TokenHash, reset.ExpiresAt, reset.CreatedAt).Scan(&reset.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &reset, http.StatusCreated, nil
+}
+
+// Consume mark an unused and unexpired token as used, it return the id of its user
+func (r *PasswordResetRepositoryImpl) Consume(ctx context.Context, tokenHash string) (int64, int, error) {
+	now := time.Now().UnixMilli()
+
+	var userID int64
+	err := r.db.QueryRowContext(ctx,
+		"UPDATE password_resets SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1 RETURNING user_id",
+		now, tokenHash).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidToken, errorer.ErrInvalidToken.Error())
+		}
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return userID, http.StatusOK, nil
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
@@ -18,263 +19,596 @@ import (
 type PostRepository interface {
 	FindAll(ctx context.Context, filter entity.FindAllPostRequest) ([]entity.Post, *common.Meta, int, error)
 	FindByID(ctx context.Context, id int64) (*entity.Post, int, error)
-	CreatePost(ctx context.Context, ent entity.Post) (int, error)
-	CreateComment(ctx context.Context, ent entity.Comment) (int, error)
+	FindVisibleByID(ctx context.Context, id int64, viewerID int64) (*entity.Post, int, error)
+	CreatePost(ctx context.Context, ent entity.Post) (*entity.Post, int, error)
+	CreateComment(ctx context.Context, ent entity.Comment) (*entity.Comment, int, error)
+	FindDetailByID(ctx context.Context, id int64, viewerID int64, commentLimit int) (*entity.Post, int, error)
+	FindComments(ctx context.Context, filter entity.FindAllCommentRequest) ([]entity.Comment, *common.Meta, int, error)
+	FindTimeline(ctx context.Context, filter entity.FindTimelineRequest) ([]entity.Post, int, error)
+	UpdatePost(ctx context.Context, ent entity.Post) (int, error)
+	DeletePost(ctx context.Context, id int64, userID int64) (int, error)
+	FindCommentByID(ctx context.Context, id int64) (*entity.Comment, int, error)
+	UpdateComment(ctx context.Context, id int64, content string, editedBy int64) (int, error)
+	DeleteComment(ctx context.Context, id int64, deletedBy int64) (int, error)
+	FindCommentRevisions(ctx context.Context, commentID int64) ([]entity.CommentRevision, int, error)
+	ReactPost(ctx context.Context, postID int64, userID int64, reaction string) (int, error)
+	UnreactPost(ctx context.Context, postID int64, userID int64) (int, error)
+	ReactComment(ctx context.Context, commentID int64, userID int64, reaction string) (int, error)
+	UnreactComment(ctx context.Context, commentID int64, userID int64) (int, error)
+	ReindexSearch(ctx context.Context) (int64, int, error)
 }
 
-func NewPostRepository(logger zerolog.Logger, db *sql.DB) PostRepository {
+// NewPostRepository create the post repository, searchLanguage is the postgres text search
+// configuration used to index and query post content, e.g. "english" or "simple"
+func NewPostRepository(logger zerolog.Logger, db *sql.DB, searchLanguage string) PostRepository {
 	return &PostRepositoryImpl{
-		logger: logger,
-		db:     db,
+		logger:         logger,
+		db:             db,
+		searchLanguage: searchLanguage,
 	}
 }
 
 type PostRepositoryImpl struct {
-	logger zerolog.Logger
-	db     *sql.DB
+	logger         zerolog.Logger
+	db             *sql.DB
+	searchLanguage string
 }
 
-func (r *PostRepositoryImpl) FindAll(ctx context.Context, filter entity.FindAllPostRequest) ([]entity.Post, *common.Meta, int, error) {
-	type PostRaw struct {
-		ID                   int64
-		ContentHTML          string
-		Tags                 string
-		UserID               int64
-		CreatedAt            int64
-		UpdatedAt            int64
-		UserIDPost           int
-		UserName             string
-		UserImageURL         string
-		UserFriendCnt        int64
-		UserCreatedAt        int64
-		CommentID            *int64
-		CommentContent       *string
-		CommentPostID        *int64
-		CommentCreatedAt     *int64
-		CommentUserID        *int64
-		CommentUserName      *string
-		CommentUserImageURL  *string
-		CommentUserFriendCnt *int64
+const (
+	postColumns = `
+			p.id,
+			p.content_html,
+			COALESCE((
+				SELECT ARRAY_AGG(t.name ORDER BY pt.sort_order)
+				FROM post_tags pt
+				JOIN tags t ON pt.tag_id = t.id
+				WHERE pt.post_id = p.id
+			), '{}'),
+			p.user_id,
+			p.created_at,
+			p.updated_at,
+			p.like_count,
+			p.love_count,
+			p.laugh_count,
+			p.visibility,
+			u.id,
+			u.name,
+			u.image_url,
+			u.friend_count,
+			u.created_at`
+	commentColumns = `
+			c.id,
+			c.content,
+			c.post_id,
+			c.user_id,
+			c.created_at,
+			c.updated_at,
+			COALESCE(c.parent_id, 0),
+			c.depth,
+			c.reply_count,
+			c.edited_at,
+			c.deleted_at,
+			c.like_count,
+			c.love_count,
+			c.laugh_count,
+			u.id,
+			u.name,
+			u.image_url,
+			u.friend_count`
+)
+
+// snippetOptions configure TS_HEADLINE to return up to two short fragments with the matches
+// wrapped in the snippet markers, the markers are stripped from the text beforehand
+var snippetOptions = fmt.Sprintf(
+	`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=20, MinWords=8`,
+	entity.SnippetMatchStart, entity.SnippetMatchEnd)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPost scan the postColumns of a row, followed by the extra columns selected after them
+func scanPost(row rowScanner, extra ...interface{}) (entity.Post, error) {
+	post := entity.Post{Comments: []entity.Comment{}}
+	dest := []interface{}{
+		&post.ID,
+		&post.ContentHtml,
+		pq.Array(&post.Tags),
+		&post.UserID,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+		&post.Reactions.Like,
+		&post.Reactions.Love,
+		&post.Reactions.Laugh,
+		&post.Visibility,
+		&post.Creator.ID,
+		&post.Creator.Name,
+		&post.Creator.ImageUrl,
+		&post.Creator.FriendCount,
+		&post.Creator.CreatedAt,
 	}
+	err := row.Scan(append(dest, extra...)...)
+	return post, err
+}
+
+func scanComment(row rowScanner) (entity.Comment, error) {
+	comment := entity.Comment{}
+	err := row.Scan(
+		&comment.ID,
+		&comment.Content,
+		&comment.PostID,
+		&comment.UserID,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.ReplyCount,
+		&comment.EditedAt,
+		&comment.DeletedAt,
+		&comment.Reactions.Like,
+		&comment.Reactions.Love,
+		&comment.Reactions.Laugh,
+		&comment.Creator.ID,
+		&comment.Creator.Name,
+		&comment.Creator.ImageUrl,
+		&comment.Creator.FriendCount,
+	)
+	return comment, err
+}
+
+// visiblePostCondition match the posts aliased p the viewer bound to placeholder may see: their own posts,
+// and posts of users they have no block with that are public, shared with friends or list them in the audience
+func visiblePostCondition(viewer string) string {
+	return `(p.user_id NOT IN (` + blockedIDsQuery(viewer) + `) AND (
+		p.user_id = ` + viewer + `
+		OR p.visibility = '` + entity.PostVisibilityPublic + `'
+		OR (p.visibility = '` + entity.PostVisibilityFriends + `' AND EXISTS (
+			SELECT 1 FROM friendships vf
+			WHERE ((vf.user_id = p.user_id AND vf.added_by = ` + viewer + `) OR (vf.user_id = ` + viewer + ` AND vf.added_by = p.user_id))
+			AND vf.status = '` + entity.FriendshipStatusAccepted + `'
+		))
+		OR (p.visibility = '` + entity.PostVisibilityCustom + `' AND EXISTS (
+			SELECT 1 FROM post_audiences pa WHERE pa.post_id = p.id AND pa.user_id = ` + viewer + `
+		))
+	))`
+}
+
+// postVisible report whether the viewer may see the post, commenting and reacting follow the same rule
+func postVisible(ctx context.Context, q rowQueryer, postID int64, viewerID int64) (bool, error) {
+	var visible bool
+	err := q.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM posts p WHERE p.id = $1 AND "+visiblePostCondition("$2")+")",
+		postID, viewerID).Scan(&visible)
+	return visible, err
+}
+
+// postConditions build the WHERE conditions shared by every post listing, placeholders start at argIndex
+func (r *PostRepositoryImpl) postConditions(filter entity.FindAllPostRequest, argIndex int) ([]string, []interface{}, int) {
 	var conditions []string
 	var args []interface{}
-	// Add conditions based on filter criteria
-	argIndex := 1 // Start index for placeholder arguments
+	if filter.UserID != 0 {
+		conditions = append(conditions, visiblePostCondition(fmt.Sprintf("$%d", argIndex)))
+		args = append(args, filter.UserID)
+		argIndex++
+	}
+
 	if len(filter.Tags) > 0 {
-		tagConditions := make([]string, len(filter.Tags))
-		for i, tag := range filter.Tags {
-			tagConditions[i] = "LOWER(p.tags) LIKE $" + fmt.Sprint(argIndex)
-			args = append(args, "%"+strings.ToLower(tag)+"%")
+		matchingTags := fmt.Sprintf(`
+			SELECT COUNT(*) FROM post_tags pt
+			JOIN tags t ON pt.tag_id = t.id
+			WHERE pt.post_id = p.id AND t.name = ANY($%d)`, argIndex)
+		args = append(args, pq.Array(filter.Tags))
+		argIndex++
+		if filter.TagMode == entity.TagModeOr {
+			conditions = append(conditions, "("+matchingTags+") > 0")
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s) = $%d", matchingTags, argIndex))
+			args = append(args, len(filter.Tags))
 			argIndex++
 		}
-		conditions = append(conditions, "("+strings.Join(tagConditions, " AND ")+")")
 	}
 
 	if filter.Search != "" {
-		conditions = append(conditions, "LOWER(p.content_html) LIKE $"+fmt.Sprint(argIndex))
-		args = append(args, "%"+strings.ToLower(filter.Search)+"%")
+		conditions = append(conditions, "p.search_vector @@ "+searchQuery(argIndex))
+		args = append(args, r.searchLanguage, filter.Search)
+		argIndex += 2
+	}
+
+	if filter.AuthorID != 0 {
+		conditions = append(conditions, fmt.Sprintf("p.user_id = $%d", argIndex))
+		args = append(args, filter.AuthorID)
+		argIndex++
+	}
+
+	if filter.CreatedFrom != 0 {
+		conditions = append(conditions, fmt.Sprintf("p.created_at >= $%d", argIndex))
+		args = append(args, filter.CreatedFrom)
 		argIndex++
 	}
 
+	if filter.CreatedTo != 0 {
+		conditions = append(conditions, fmt.Sprintf("p.created_at < $%d", argIndex))
+		args = append(args, filter.CreatedTo)
+		argIndex++
+	}
+	return conditions, args, argIndex
+}
+
+// searchQuery parse the web search syntax of the user ("quoted phrases", or, -excluded) into a tsquery,
+// the text search configuration and the search text are read from $argIndex and $argIndex+1
+func searchQuery(argIndex int) string {
+	return fmt.Sprintf("WEBSEARCH_TO_TSQUERY($%d::REGCONFIG, $%d)", argIndex, argIndex+1)
+}
+
+func (r *PostRepositoryImpl) FindAll(ctx context.Context, filter entity.FindAllPostRequest) ([]entity.Post, *common.Meta, int, error) {
+	conditions, args, argIndex := r.postConditions(filter, 1)
+
 	// Construct the WHERE clause
 	var whereClause string
 	if len(conditions) > 0 {
 		whereClause += "WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	// Count every matching post, not only the current page
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM posts p "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	argsQuery := []interface{}{}
+	argsQuery = append(argsQuery, args...)
+
+	// When searching, rank by relevance and cut a highlighted snippet out of the matching text
+	snippetColumn, orderClause := "''", "p.created_at DESC, p.id DESC"
+	if filter.Search != "" {
+		query := searchQuery(argIndex)
+		argsQuery = append(argsQuery, r.searchLanguage, filter.Search, entity.SnippetMatchStart+entity.SnippetMatchEnd, snippetOptions)
+		snippetColumn = fmt.Sprintf(
+			"TS_HEADLINE($%d::REGCONFIG, TRANSLATE(p.content_text, $%d, ''), %s, $%d)",
+			argIndex, argIndex+2, query, argIndex+3)
+		orderClause = "TS_RANK_CD(p.search_vector, " + query + ") DESC, " + orderClause
+		argIndex += 4
+	}
+
 	// Construct the LIMIT and OFFSET clauses
 	limitOffsetClause := fmt.Sprintf("LIMIT $%d ", argIndex)
 	argIndex++
 	limitOffsetClause += fmt.Sprintf("OFFSET $%d ", argIndex)
-	argIndex++
 
-	// Construct the final query for products
-	query := `SELECT 
-			p.id,
-			p.content_html,
-			p.tags,
-			p.user_id,
-			p.created_at,
-			p.updated_at,
-			u.id as u_id,
-			u.name as u_name,
-			u.image_url as u_image_url,
-			u.friend_count as u_friend_count,
-			u.created_at as u_created_at,
-			c.id,
-			c.content,
-			c.post_id,
-			c.created_at,
-			cu.id as u_id,
-			cu.name as u_name,
-			cu.image_url as u_image_url,
-			cu.friend_count as u_friend_count
+	query := `SELECT ` + postColumns + `, ` + snippetColumn + `
 	FROM posts p
-	LEFT JOIN users u ON p.user_id = u.id 
-	LEFT JOIN comments c ON p.id = c.post_id
-	LEFT JOIN users cu ON c.user_id = cu.id
-	 ` + whereClause + ` ORDER BY 
-    p.created_at DESC ` + limitOffsetClause
-	// Construct the query to get total product count
-	posts := []entity.Post{}
-	// Execute the main query
-	argsQuery := []interface{}{}
-	argsQuery = append(argsQuery, args...)
+	JOIN users u ON p.user_id = u.id
+	 ` + whereClause + ` ORDER BY
+    ` + orderClause + ` ` + limitOffsetClause
+
 	argsQuery = append(argsQuery, filter.Limit)
 	argsQuery = append(argsQuery, filter.Offset)
 	rows, err := r.db.QueryContext(ctx, query, argsQuery...)
 	if err != nil {
-		return nil, nil, http.StatusInternalServerError, errors.Wrap(err, "failed to execute query")
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 	defer rows.Close()
-	postIDs := make(map[int64]int)
+
+	posts := []entity.Post{}
 	for rows.Next() {
-		postRaw := PostRaw{}
-
-		// var commentString []string
-		// Add more variables as needed for other columns
-		err := rows.Scan(
-			&postRaw.ID,
-			&postRaw.ContentHTML,
-			&postRaw.Tags,
-			&postRaw.UserID,
-			&postRaw.CreatedAt,
-			&postRaw.UpdatedAt,
-			&postRaw.UserID,
-			&postRaw.UserName,
-			&postRaw.UserImageURL,
-			&postRaw.UserFriendCnt,
-			&postRaw.UserCreatedAt,
-			&postRaw.CommentID,
-			&postRaw.CommentContent,
-			&postRaw.CommentPostID,
-			&postRaw.CommentCreatedAt,
-			&postRaw.CommentUserID,
-			&postRaw.CommentUserName,
-			&postRaw.CommentUserImageURL,
-			&postRaw.CommentUserFriendCnt,
-		)
+		var snippet string
+		post, err := scanPost(rows, &snippet)
 		if err != nil {
-			return nil, nil, http.StatusInternalServerError, errors.Wrap(err, "failed to scan product")
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 		}
+		post.Snippet = snippet
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = r.attachCommentPreviews(ctx, posts, filter.CommentLimit, filter.UserID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = r.attachMyReactions(ctx, posts, filter.UserID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
 
-		if postIDs[postRaw.ID] == 0 {
-			post := entity.Post{
-				ID:          postRaw.ID,
-				ContentHtml: postRaw.ContentHTML,
-				Tags:        postRaw.Tags,
-				UserID:      postRaw.UserID,
-				CreatedAt:   postRaw.CreatedAt,
-				UpdatedAt:   postRaw.UpdatedAt,
-				Comments:    []entity.Comment{},
-			}
-			post.Creator = entity.User{
-				ID:          postRaw.UserID,
-				Name:        postRaw.UserName,
-				ImageUrl:    postRaw.UserImageURL,
-				FriendCount: postRaw.UserFriendCnt,
-				CreatedAt:   postRaw.UserCreatedAt,
-			}
-			posts = append(posts, post)
-			postIDs[postRaw.ID] = len(posts) - 1
+	meta := common.Meta{
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	return posts, &meta, http.StatusOK, nil
+}
+
+// FindTimeline list posts of the user and their friends, newest first, starting after the cursor.
+// Posts are read per author through the (user_id, created_at) index and merged, so the cost
+// depends on the page size and number of friends rather than the size of the posts table.
+func (r *PostRepositoryImpl) FindTimeline(ctx context.Context, filter entity.FindTimelineRequest) ([]entity.Post, int, error) {
+	args := []interface{}{filter.UserID, filter.Limit}
+	conditions, condArgs, argIndex := r.postConditions(entity.FindAllPostRequest{
+		Tags:    filter.Tags,
+		TagMode: filter.TagMode,
+		Search:  filter.Search,
+		UserID:  filter.UserID,
+	}, 3)
+	args = append(args, condArgs...)
+
+	if filter.CursorID != 0 {
+		conditions = append(conditions, fmt.Sprintf("(p.created_at, p.id) < ($%d, $%d)", argIndex, argIndex+1))
+		args = append(args, filter.CursorCreatedAt, filter.CursorID)
+	}
+
+	var whereClause string
+	if len(conditions) > 0 {
+		whereClause = "AND " + strings.Join(conditions, " AND ")
+	}
+
+	query := `SELECT ` + postColumns + `
+	FROM (
+		SELECT $1::INTEGER AS id
+		UNION ALL
+		` + friendIDsQuery("$1") + `
+	) authors
+	CROSS JOIN LATERAL (
+		SELECT p.* FROM posts p
+		WHERE p.user_id = authors.id ` + whereClause + `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2
+	) p
+	JOIN users u ON p.user_id = u.id
+	ORDER BY p.created_at DESC, p.id DESC
+	LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	posts := []entity.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = r.attachCommentPreviews(ctx, posts, filter.CommentLimit, filter.UserID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = r.attachMyReactions(ctx, posts, filter.UserID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	return posts, http.StatusOK, nil
+}
+
+// attachCommentPreviews load the latest limit top-level comments of every post in a single query,
+// leaving out comments of users blocked by or blocking the viewer
+func (r *PostRepositoryImpl) attachCommentPreviews(ctx context.Context, posts []entity.Post, limit int, viewerID int64) error {
+	if len(posts) == 0 || limit <= 0 {
+		return nil
+	}
+
+	postIdx := make(map[int64]int, len(posts))
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIdx[post.ID] = i
+		postIDs[i] = post.ID
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at DESC, id DESC) AS rn
+			FROM comments
+			WHERE post_id = ANY($1) AND parent_id IS NULL
+			AND user_id NOT IN (`+blockedIDsQuery("$3")+`)
+		) c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.rn <= $2
+		ORDER BY c.post_id, c.rn`, pq.Array(postIDs), limit, viewerID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return err
+		}
+		i := postIdx[comment.PostID]
+		posts[i].Comments = append(posts[i].Comments, comment)
+	}
+	return rows.Err()
+}
 
-		if postRaw.CommentID != nil {
-			posts[postIDs[postRaw.ID]].Comments = append(posts[postIDs[postRaw.ID]].Comments, entity.Comment{
-				ID:        *postRaw.CommentID,
-				Content:   *postRaw.CommentContent,
-				PostID:    *postRaw.CommentPostID,
-				CreatedAt: *postRaw.CommentCreatedAt,
-				Creator: entity.User{
-					ID:          *postRaw.CommentUserID,
-					Name:        *postRaw.CommentUserName,
-					ImageUrl:    *postRaw.CommentUserImageURL,
-					FriendCount: *postRaw.CommentUserFriendCnt,
-				},
-			})
+// FindComments page through the top-level comments of a post newest first,
+// or through the replies of a comment oldest first when filter.ParentID is set
+func (r *PostRepositoryImpl) FindComments(ctx context.Context, filter entity.FindAllCommentRequest) ([]entity.Comment, *common.Meta, int, error) {
+	condition, order, targetID := "c.post_id = $1 AND c.parent_id IS NULL", "c.created_at DESC, c.id DESC", filter.PostID
+	if filter.ParentID != 0 {
+		condition, order, targetID = "c.parent_id = $1", "c.created_at, c.id", filter.ParentID
+	}
+	condition += " AND c.user_id NOT IN (" + blockedIDsQuery("$2") + ")"
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments c WHERE "+condition, targetID, filter.ViewerID).Scan(&total)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE `+condition+`
+		ORDER BY `+order+`
+		LIMIT $3 OFFSET $4`, targetID, filter.ViewerID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	comments := []entity.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
 
+	commentRefs := make([]*entity.Comment, len(comments))
+	for i := range comments {
+		commentRefs[i] = &comments[i]
+	}
+	err = r.attachMyCommentReactions(ctx, commentRefs, filter.ViewerID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 
 	meta := common.Meta{
-		Total:  len(posts),
+		Total:  total,
 		Limit:  filter.Limit,
 		Offset: filter.Offset,
 	}
 
-	return posts, &meta, http.StatusOK, nil
+	return comments, &meta, http.StatusOK, nil
 }
 
-func (r *PostRepositoryImpl) CreatePost(ctx context.Context, ent entity.Post) (int, error) {
+func (r *PostRepositoryImpl) CreatePost(ctx context.Context, ent entity.Post) (*entity.Post, int, error) {
 	ent.CreatedAt = time.Now().UnixMilli()
 	ent.UpdatedAt = time.Now().UnixMilli()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
 	// insert post
-	err := r.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		`
-		INSERT INTO posts (content_html, tags, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id
+		INSERT INTO posts (content_html, content_text, search_vector, search_language, visibility, user_id, created_at, updated_at)
+		VALUES ($1, $2, TO_TSVECTOR($3::REGCONFIG, $2), $3, $4, $5, $6, $7) RETURNING id
 		`,
-		&ent.ContentHtml, &ent.Tags, &ent.UserID, &ent.CreatedAt, &ent.UpdatedAt,
+		&ent.ContentHtml, &ent.ContentText, r.searchLanguage, &ent.Visibility, &ent.UserID, &ent.CreatedAt, &ent.UpdatedAt,
 	).Scan(&ent.ID)
 
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 
-	return http.StatusOK, nil
+	err = setPostTags(ctx, tx, ent.ID, ent.Tags, ent.CreatedAt)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	code, err := setPostAudience(ctx, tx, ent.ID, ent.Visibility, ent.Audience)
+	if err != nil {
+		return nil, code, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &ent, http.StatusOK, nil
 }
 
 // create comment
-func (r *PostRepositoryImpl) CreateComment(ctx context.Context, ent entity.Comment) (int, error) {
+func (r *PostRepositoryImpl) CreateComment(ctx context.Context, ent entity.Comment) (*entity.Comment, int, error) {
 	ent.CreatedAt = time.Now().UnixMilli()
 	ent.UpdatedAt = time.Now().UnixMilli()
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	// lock the post so its owner and visibility cannot change before the comment is saved
 	var tgtUserId int64
-	// get post by id
-	err := r.db.QueryRowContext(
-		ctx,
-		"SELECT user_id FROM posts WHERE id = $1",
-		&ent.PostID,
-	).Scan(&tgtUserId)
+	err = tx.QueryRowContext(ctx, "SELECT user_id FROM posts WHERE id = $1 FOR SHARE", ent.PostID).Scan(&tgtUserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
 
+	// commenting follows the visibility of the post
+	visible, err := postVisible(ctx, tx, ent.PostID, ent.UserID)
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	if !visible {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
 	}
 
 	if tgtUserId != ent.UserID {
 		// check if user is friend
-		var id int64
-		_ = r.db.QueryRowContext(
-			ctx,
-			"SELECT id FROM friendships WHERE (user_id = $1 AND added_by = $2) OR (user_id = $2 AND added_by = $1)",
-			&ent.UserID, &tgtUserId,
-		).Scan(&id)
-
-		if id == 0 {
-			return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "user is not friend")
+		friend, err := isFriend(ctx, tx, ent.UserID, tgtUserId)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+
+		if !friend {
+			return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "user is not friend")
 		}
 	}
+
+	var parentID sql.NullInt64
+	if ent.ParentID != 0 {
+		parentID = sql.NullInt64{Int64: ent.ParentID, Valid: true}
+	}
+
 	// insert comment
-	err = r.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		`
-		INSERT INTO comments (content, created_at, updated_at, post_id, user_id) VALUES ($1, $2, $3, $4, $5) RETURNING id
+		INSERT INTO comments (content, created_at, updated_at, post_id, user_id, parent_id, depth) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
 		`,
-		&ent.Content, &ent.CreatedAt, &ent.UpdatedAt, &ent.PostID, &ent.UserID,
+		&ent.Content, &ent.CreatedAt, &ent.UpdatedAt, &ent.PostID, &ent.UserID, parentID, &ent.Depth,
 	).Scan(&ent.ID)
 
 	if err != nil {
-		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
 	}
 
-	return http.StatusOK, nil
+	if parentID.Valid {
+		_, err = tx.ExecContext(ctx, "UPDATE comments SET reply_count = reply_count + 1 WHERE id = $1", parentID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &ent, http.StatusOK, nil
 }
 
 // find by id
 func (r *PostRepositoryImpl) FindByID(ctx context.Context, id int64) (*entity.Post, int, error) {
 	var post entity.Post
-	row := r.db.QueryRowContext(ctx, "SELECT id, content_html, tags, user_id, created_at, updated_at FROM posts WHERE id = $1", id)
-	err := row.Scan(&post.ID, &post.ContentHtml, &post.Tags, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
+	row := r.db.QueryRowContext(ctx, "SELECT id, content_html, user_id, created_at, updated_at FROM posts WHERE id = $1", id)
+	err := row.Scan(&post.ID, &post.ContentHtml, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
@@ -283,3 +617,248 @@ func (r *PostRepositoryImpl) FindByID(ctx context.Context, id int64) (*entity.Po
 	}
 	return &post, http.StatusOK, nil
 }
+
+// FindVisibleByID find a post the viewer may see, posts hidden from the viewer are reported as not found
+func (r *PostRepositoryImpl) FindVisibleByID(ctx context.Context, id int64, viewerID int64) (*entity.Post, int, error) {
+	var post entity.Post
+	row := r.db.QueryRowContext(ctx, `
+		SELECT p.id, p.content_html, p.visibility, p.user_id, p.created_at, p.updated_at
+		FROM posts p
+		WHERE p.id = $1 AND `+visiblePostCondition("$2"), id, viewerID)
+	err := row.Scan(&post.ID, &post.ContentHtml, &post.Visibility, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &post, http.StatusOK, nil
+}
+
+// setPostAudience replace the audience of a post, it is emptied unless visibility is custom.
+// Every audience member must be an existing user.
+func setPostAudience(ctx context.Context, tx *sql.Tx, postID int64, visibility string, audience []int64) (int, error) {
+	_, err := tx.ExecContext(ctx, "DELETE FROM post_audiences WHERE post_id = $1", postID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if visibility != entity.PostVisibilityCustom || len(audience) == 0 {
+		return http.StatusOK, nil
+	}
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO post_audiences (post_id, user_id) SELECT $1, u.id FROM users u WHERE u.id = ANY($2)",
+		postID, pq.Array(audience))
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if int(row) != len(audience) {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "audience contains an unknown user")
+	}
+	return http.StatusOK, nil
+}
+
+// FindDetailByID find a post with its creator and the latest commentLimit comments
+func (r *PostRepositoryImpl) FindDetailByID(ctx context.Context, id int64, viewerID int64, commentLimit int) (*entity.Post, int, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+postColumns+`
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = $1 AND `+visiblePostCondition("$2"), id, viewerID)
+	post, err := scanPost(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	posts := []entity.Post{post}
+	err = r.attachCommentPreviews(ctx, posts, commentLimit, viewerID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = r.attachMyReactions(ctx, posts, viewerID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	return &posts[0], http.StatusOK, nil
+}
+
+// UpdatePost update content and tags of a post owned by ent.UserID,
+// visibility and audience are only replaced when ent.Visibility is set
+func (r *PostRepositoryImpl) UpdatePost(ctx context.Context, ent entity.Post) (int, error) {
+	ent.UpdatedAt = time.Now().UnixMilli()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE posts SET content_html = $1, content_text = $2, search_vector = TO_TSVECTOR($3::REGCONFIG, $2), search_language = $3, updated_at = $4 WHERE id = $5 AND user_id = $6",
+		ent.ContentHtml, ent.ContentText, r.searchLanguage, ent.UpdatedAt, ent.ID, ent.UserID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	err = setPostTags(ctx, tx, ent.ID, ent.Tags, ent.UpdatedAt)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if ent.Visibility != "" {
+		_, err = tx.ExecContext(ctx, "UPDATE posts SET visibility = $1 WHERE id = $2", ent.Visibility, ent.ID)
+		if err != nil {
+			return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+
+		code, err := setPostAudience(ctx, tx, ent.ID, ent.Visibility, ent.Audience)
+		if err != nil {
+			return code, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// DeletePost delete a post owned by userID, its comments are removed by the foreign key cascade
+func (r *PostRepositoryImpl) DeletePost(ctx context.Context, id int64, userID int64) (int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *PostRepositoryImpl) FindCommentByID(ctx context.Context, id int64) (*entity.Comment, int, error) {
+	var comment entity.Comment
+	row := r.db.QueryRowContext(ctx, "SELECT id, content, post_id, user_id, COALESCE(parent_id, 0), depth, reply_count, created_at, updated_at, edited_at, deleted_at FROM comments WHERE id = $1", id)
+	err := row.Scan(&comment.ID, &comment.Content, &comment.PostID, &comment.UserID, &comment.ParentID, &comment.Depth, &comment.ReplyCount, &comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt, &comment.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &comment, http.StatusOK, nil
+}
+
+// UpdateComment replace the content of a comment that is not deleted, saving the previous content as a revision
+func (r *PostRepositoryImpl) UpdateComment(ctx context.Context, id int64, content string, editedBy int64) (int, error) {
+	return r.reviseComment(ctx, id, editedBy, "UPDATE comments SET content = $1, updated_at = $2, edited_at = $2 WHERE id = $3", content)
+}
+
+// DeleteComment turn a comment into a tombstone, its content is kept only as a revision so replies stay attached
+func (r *PostRepositoryImpl) DeleteComment(ctx context.Context, id int64, deletedBy int64) (int, error) {
+	return r.reviseComment(ctx, id, deletedBy, "UPDATE comments SET content = $1, updated_at = $2, deleted_at = $2 WHERE id = $3", "")
+}
+
+// reviseComment save the current content of a comment as a revision then apply update,
+// which receives the new content, the current time and the comment id
+func (r *PostRepositoryImpl) reviseComment(ctx context.Context, id int64, userID int64, update string, content string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRowContext(ctx, "SELECT content FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	now := time.Now().UnixMilli()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO comment_revisions (comment_id, content, edited_by, created_at) VALUES ($1, $2, $3, $4)",
+		id, previous, userID, now)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, update, content, now, id)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// FindCommentRevisions list every previous version of a comment, newest first
+func (r *PostRepositoryImpl) FindCommentRevisions(ctx context.Context, commentID int64) ([]entity.CommentRevision, int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, comment_id, content, edited_by, created_at FROM comment_revisions WHERE comment_id = $1 ORDER BY created_at DESC, id DESC",
+		commentID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	revisions := []entity.CommentRevision{}
+	for rows.Next() {
+		rev := entity.CommentRevision{}
+		err = rows.Scan(&rev.ID, &rev.CommentID, &rev.Content, &rev.EditedBy, &rev.CreatedAt)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return revisions, http.StatusOK, nil
+}
+
+// ReindexSearch rebuild the search vector of the posts indexed with another language than the configured one
+func (r *PostRepositoryImpl) ReindexSearch(ctx context.Context) (int64, int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE posts SET search_vector = TO_TSVECTOR($1::REGCONFIG, content_text), search_language = $1 WHERE search_language <> $1",
+		r.searchLanguage)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return row, http.StatusOK, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// reactionCountColumns map every reaction type to its counter column on posts and comments
+var reactionCountColumns = map[string]string{
+	entity.ReactionLike:  "like_count",
+	entity.ReactionLove:  "love_count",
+	entity.ReactionLaugh: "laugh_count",
+}
+
+// reactionTarget describe a table that can be reacted on
+type reactionTarget struct {
+	table         string
+	reactionTable string
+	column        string
+	// lockQuery lock the target row and return the id and the owner of the post it belongs to
+	lockQuery string
+}
+
+var (
+	postReactionTarget = reactionTarget{
+		table:         "posts",
+		reactionTable: "post_reactions",
+		column:        "post_id",
+		lockQuery:     "SELECT id, user_id FROM posts WHERE id = $1 FOR UPDATE",
+	}
+	commentReactionTarget = reactionTarget{
+		table:         "comments",
+		reactionTable: "comment_reactions",
+		column:        "comment_id",
+		lockQuery:     "SELECT p.id, p.user_id FROM comments c JOIN posts p ON c.post_id = p.id WHERE c.id = $1 AND c.deleted_at IS NULL FOR UPDATE OF c",
+	}
+)
+
+func (r *PostRepositoryImpl) ReactPost(ctx context.Context, postID int64, userID int64, reaction string) (int, error) {
+	return r.react(ctx, postReactionTarget, postID, userID, reaction)
+}
+
+func (r *PostRepositoryImpl) UnreactPost(ctx context.Context, postID int64, userID int64) (int, error) {
+	return r.unreact(ctx, postReactionTarget, postID, userID)
+}
+
+func (r *PostRepositoryImpl) ReactComment(ctx context.Context, commentID int64, userID int64, reaction string) (int, error) {
+	return r.react(ctx, commentReactionTarget, commentID, userID, reaction)
+}
+
+func (r *PostRepositoryImpl) UnreactComment(ctx context.Context, commentID int64, userID int64) (int, error) {
+	return r.unreact(ctx, commentReactionTarget, commentID, userID)
+}
+
+// react set the reaction of userID on the target, replacing any previous one, and update the counters
+// in the same transaction. The target row stays locked until commit so concurrent reactions are serialized.
+func (r *PostRepositoryImpl) react(ctx context.Context, target reactionTarget, id int64, userID int64, reaction string) (int, error) {
+	column, ok := reactionCountColumns[reaction]
+	if !ok {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	var postID, ownerID int64
+	err = tx.QueryRowContext(ctx, target.lockQuery, id).Scan(&postID, &ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	// same rule as commenting, the post must be visible to the user and only friends of the post owner may react
+	visible, err := postVisible(ctx, tx, postID, userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	if !visible {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	if ownerID != userID {
+		friend, err := isFriend(ctx, tx, userID, ownerID)
+		if err != nil {
+			return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		if !friend {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "user is not friend")
+		}
+	}
+
+	var previous string
+	err = tx.QueryRowContext(ctx,
+		"SELECT type FROM "+target.reactionTable+" WHERE "+target.column+" = $1 AND user_id = $2",
+		id, userID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if previous == reaction {
+		return http.StatusOK, nil
+	}
+
+	now := time.Now().UnixMilli()
+	counters := column + " = " + column + " + 1"
+	if previous == "" {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO "+target.reactionTable+" ("+target.column+", user_id, type, created_at) VALUES ($1, $2, $3, $4)",
+			id, userID, reaction, now)
+	} else {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE "+target.reactionTable+" SET type = $1, created_at = $2 WHERE "+target.column+" = $3 AND user_id = $4",
+			reaction, now, id, userID)
+		previousColumn := reactionCountColumns[previous]
+		counters += ", " + previousColumn + " = GREATEST(" + previousColumn + " - 1, 0)"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE "+target.table+" SET "+counters+" WHERE id = $1", id)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// unreact remove the reaction of userID from the target and decrement its counter in the same transaction
+func (r *PostRepositoryImpl) unreact(ctx context.Context, target reactionTarget, id int64, userID int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	var postID, ownerID int64
+	err = tx.QueryRowContext(ctx, target.lockQuery, id).Scan(&postID, &ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	var previous string
+	err = tx.QueryRowContext(ctx,
+		"DELETE FROM "+target.reactionTable+" WHERE "+target.column+" = $1 AND user_id = $2 RETURNING type",
+		id, userID).Scan(&previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	column := reactionCountColumns[previous]
+	_, err = tx.ExecContext(ctx, "UPDATE "+target.table+" SET "+column+" = GREATEST("+column+" - 1, 0) WHERE id = $1", id)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// attachMyReactions set the reaction of viewerID on every post and on their comment previews
+func (r *PostRepositoryImpl) attachMyReactions(ctx context.Context, posts []entity.Post, viewerID int64) error {
+	if len(posts) == 0 || viewerID == 0 {
+		return nil
+	}
+
+	postIdx := make(map[int64]int, len(posts))
+	postIDs := make([]int64, len(posts))
+	comments := []*entity.Comment{}
+	for i := range posts {
+		postIdx[posts[i].ID] = i
+		postIDs[i] = posts[i].ID
+		for j := range posts[i].Comments {
+			comments = append(comments, &posts[i].Comments[j])
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT post_id, type FROM post_reactions WHERE user_id = $1 AND post_id = ANY($2)",
+		viewerID, pq.Array(postIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int64
+		var reaction string
+		if err := rows.Scan(&postID, &reaction); err != nil {
+			return err
+		}
+		posts[postIdx[postID]].MyReaction = reaction
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return r.attachMyCommentReactions(ctx, comments, viewerID)
+}
+
+// attachMyCommentReactions set the reaction of viewerID on every comment
+func (r *PostRepositoryImpl) attachMyCommentReactions(ctx context.Context, comments []*entity.Comment, viewerID int64) error {
+	if len(comments) == 0 || viewerID == 0 {
+		return nil
+	}
+
+	commentIdx := make(map[int64]*entity.Comment, len(comments))
+	commentIDs := make([]int64, len(comments))
+	for i, comment := range comments {
+		commentIdx[comment.ID] = comment
+		commentIDs[i] = comment.ID
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT comment_id, type FROM comment_reactions WHERE user_id = $1 AND comment_id = ANY($2)",
+		viewerID, pq.Array(commentIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID int64
+		var reaction string
+		if err := rows.Scan(&commentID, &reaction); err != nil {
+			return err
+		}
+		commentIdx[commentID].MyReaction = reaction
+	}
+	return rows.Err()
+}
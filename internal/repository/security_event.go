@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event entity.SecurityEvent) (int, error)
+	DeleteOlderThan(ctx context.Context, before int64) (int64, int, error)
+}
+
+func NewSecurityEventRepository(logger zerolog.Logger, db *sql.DB) SecurityEventRepository {
+	return &SecurityEventRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type SecurityEventRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+func (r *SecurityEventRepositoryImpl) Create(ctx context.Context, event entity.SecurityEvent) (int, error) {
+	event.CreatedAt = time.Now().UnixMilli()
+
+	// NULLIF keep the event when no user matched the credential
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO security_events (user_id, event_type, credential_type, credential_value, ip_address, created_at)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6)`,
+		event.UserID, event.EventType, event.CredentialType, event.CredentialValue, event.IPAddress, event.CreatedAt)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusCreated, nil
+}
+
+// DeleteOlderThan remove security events created before the given unix milli and return how many were removed
+func (r *SecurityEventRepositoryImpl) DeleteOlderThan(ctx context.Context, before int64) (int64, int, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM security_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return row, http.StatusOK, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session entity.Session) (*entity.Session, int, error)
+	FindByID(ctx context.Context, id int64) (*entity.Session, int, error)
+	FindByRefreshToken(ctx context.Context, tokenHash string) (*entity.Session, int, error)
+	Rotate(ctx context.Context, id int64, oldTokenHash string, newTokenHash string, expiresAt int64) (int, error)
+	Revoke(ctx context.Context, id int64, userID int64) (int, error)
+	RevokeAllByUserID(ctx context.Context, userID int64) (int, error)
+	RevokeAllExcept(ctx context.Context, userID int64, sessionID int64) (int, error)
+}
+
+func NewSessionRepository(logger zerolog.Logger, db *sql.DB) SessionRepository {
+	return &SessionRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type SessionRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+func (r *SessionRepositoryImpl) Create(ctx context.Context, session entity.Session) (*entity.Session, int, error) {
+	session.CreatedAt = time.Now().UnixMilli()
+	session.UpdatedAt = time.Now().UnixMilli()
+
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO sessions (user_id, refresh_token_hash, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		session.UserID, session.RefreshTokenHash, session.ExpiresAt, session.CreatedAt, session.UpdatedAt).Scan(&session.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	return &session, http.StatusCreated, nil
+}
+
+func (r *SessionRepositoryImpl) FindByID(ctx context.Context, id int64) (*entity.Session, int, error) {
+	var session entity.Session
+
+	row := r.db.QueryRowContext(ctx, "SELECT id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at FROM sessions WHERE id = $1", id)
+	err := row.Scan(&session.ID, &session.UserID, &session.RefreshTokenHash, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &session, http.StatusOK, nil
+}
+
+func (r *SessionRepositoryImpl) FindByRefreshToken(ctx context.Context, tokenHash string) (*entity.Session, int, error) {
+	var session entity.Session
+
+	row := r.db.QueryRowContext(ctx, "SELECT id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at FROM sessions WHERE refresh_token_hash = $1", tokenHash)
+	err := row.Scan(&session.ID, &session.UserID, &session.RefreshTokenHash, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &session, http.StatusOK, nil
+}
+
+// Rotate replace the refresh token of an active session, the old token must still match so a token can only be used once
+func (r *SessionRepositoryImpl) Rotate(ctx context.Context, id int64, oldTokenHash string, newTokenHash string, expiresAt int64) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE sessions SET refresh_token_hash = $1, expires_at = $2, updated_at = $3 WHERE id = $4 AND refresh_token_hash = $5 AND revoked_at IS NULL",
+		newTokenHash, expiresAt, time.Now().UnixMilli(), id, oldTokenHash)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusUnauthorized, errors.Wrap(errorer.ErrUnauthorized, errorer.ErrUnauthorized.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *SessionRepositoryImpl) Revoke(ctx context.Context, id int64, userID int64) (int, error) {
+	now := time.Now().UnixMilli()
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL",
+		now, id, userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (r *SessionRepositoryImpl) RevokeAllByUserID(ctx context.Context, userID int64) (int, error) {
+	now := time.Now().UnixMilli()
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = $1, updated_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		now, userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// RevokeAllExcept revoke every active session of the user but sessionID
+func (r *SessionRepositoryImpl) RevokeAllExcept(ctx context.Context, userID int64, sessionID int64) (int, error) {
+	now := time.Now().UnixMilli()
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = $1, updated_at = $1 WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL",
+		now, userID, sessionID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
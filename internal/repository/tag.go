@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type TagRepository interface {
+	FindTrending(ctx context.Context, since int64, limit int) ([]entity.TrendingTag, int, error)
+}
+
+func NewTagRepository(logger zerolog.Logger, db *sql.DB) TagRepository {
+	return &TagRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type TagRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+// FindTrending rank tags by the number of public posts tagged with them since the given unix milli,
+// ties go to the tag used most recently
+func (r *TagRepositoryImpl) FindTrending(ctx context.Context, since int64, limit int) ([]entity.TrendingTag, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.name, COUNT(*) AS post_count, MAX(pt.created_at) AS last_used_at
+		FROM post_tags pt
+		JOIN tags t ON pt.tag_id = t.id
+		JOIN posts p ON pt.post_id = p.id
+		WHERE pt.created_at >= $1 AND p.visibility = $3
+		GROUP BY t.id, t.name
+		ORDER BY post_count DESC, last_used_at DESC, t.name
+		LIMIT $2`, since, limit, entity.PostVisibilityPublic)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer rows.Close()
+
+	tags := []entity.TrendingTag{}
+	for rows.Next() {
+		tag := entity.TrendingTag{}
+		if err := rows.Scan(&tag.Name, &tag.PostCount, &tag.LastUsedAt); err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return tags, http.StatusOK, nil
+}
+
+// setPostTags make tags the tag list of a post, creating missing tags. Tags the post already had keep
+// their original creation time, so editing a post does not count as new usage for trending.
+func setPostTags(ctx context.Context, tx *sql.Tx, postID int64, tags []string, now int64) error {
+	_, err := tx.ExecContext(ctx,
+		"DELETE FROM post_tags WHERE post_id = $1 AND tag_id NOT IN (SELECT id FROM tags WHERE name = ANY($2))",
+		postID, pq.Array(tags))
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO tags (name, created_at) SELECT UNNEST($1::TEXT[]), $2 ON CONFLICT (name) DO NOTHING",
+		pq.Array(tags), now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO post_tags (post_id, tag_id, sort_order, created_at)
+		SELECT $1, t.id, input.sort_order, $3
+		FROM UNNEST($2::TEXT[]) WITH ORDINALITY AS input(name, sort_order)
+		JOIN tags t ON t.name = input.name
+		ON CONFLICT (post_id, tag_id) DO UPDATE SET sort_order = EXCLUDED.sort_order`,
+		postID, pq.Array(tags), now)
+	return err
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID int64) (*entity.TwoFactor, int, error)
+	SavePending(ctx context.Context, userID int64, secret string) (int, error)
+	Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) (int, error)
+	Delete(ctx context.Context, userID int64) (int, error)
+	UseStep(ctx context.Context, userID int64, step int64) (int, error)
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (int, error)
+	CreateChallenge(ctx context.Context, challenge entity.LoginChallenge) (*entity.LoginChallenge, int, error)
+	FindChallenge(ctx context.Context, tokenHash string, maxAttempts int) (*entity.LoginChallenge, int, error)
+	AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (*entity.LoginChallenge, int, error)
+	DeleteChallenge(ctx context.Context, id int64) (int, error)
+}
+
+func NewTwoFactorRepository(logger zerolog.Logger, db *sql.DB) TwoFactorRepository {
+	return &TwoFactorRepositoryImpl{
+		logger: logger,
+		db:     db,
+	}
+}
+
+type TwoFactorRepositoryImpl struct {
+	logger zerolog.Logger
+	db     *sql.DB
+}
+
+func (r *TwoFactorRepositoryImpl) FindByUserID(ctx context.Context, userID int64) (*entity.TwoFactor, int, error) {
+	var tf entity.TwoFactor
+
+	err := r.db.QueryRowContext(ctx,
+		"SELECT user_id, secret, enabled_at, last_used_step, created_at, updated_at FROM two_factors WHERE user_id = $1",
+		userID).Scan(&tf.UserID, &tf.Secret, &tf.EnabledAt, &tf.LastUsedStep, &tf.CreatedAt, &tf.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &tf, http.StatusOK, nil
+}
+
+// SavePending start or restart an enrollment with a new secret, an enabled setup is left untouched
+func (r *TwoFactorRepositoryImpl) SavePending(ctx context.Context, userID int64, secret string) (int, error) {
+	now := time.Now().UnixMilli()
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO two_factors (user_id, secret, created_at, updated_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = EXCLUDED.updated_at
+		WHERE two_factors.enabled_at IS NULL`,
+		userID, secret, now)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusConflict, errors.Wrap(errorer.ErrTwoFactorEnabled, errorer.ErrTwoFactorEnabled.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// Enable finish an enrollment confirmed with the code of step and replace the recovery codes of the user
+func (r *TwoFactorRepositoryImpl) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) (int, error) {
+	now := time.Now().UnixMilli()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE two_factors SET enabled_at = $1, last_used_step = $2, updated_at = $1 WHERE user_id = $3 AND enabled_at IS NULL",
+		now, step, userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusConflict, errors.Wrap(errorer.ErrTwoFactorEnabled, errorer.ErrTwoFactorEnabled.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM two_factor_recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO two_factor_recovery_codes (user_id, code_hash, created_at) SELECT $1, UNNEST($2::TEXT[]), $3",
+		userID, pq.Array(recoveryCodeHashes), now)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// Delete remove the TOTP setup and the recovery codes of the user
+func (r *TwoFactorRepositoryImpl) Delete(ctx context.Context, userID int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM two_factor_recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM two_factors WHERE user_id = $1", userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// UseStep record that the code of step was accepted, it fail when a code of that step or a later one
+// was already accepted so a code cannot be replayed
+func (r *TwoFactorRepositoryImpl) UseStep(ctx context.Context, userID int64, step int64) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE two_factors SET last_used_step = $1, updated_at = $2 WHERE user_id = $3 AND last_used_step < $1",
+		step, time.Now().UnixMilli(), userID)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidCode, errorer.ErrInvalidCode.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// UseRecoveryCode mark an unused recovery code of the user as used
+func (r *TwoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE two_factor_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL",
+		time.Now().UnixMilli(), userID, codeHash)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidCode, errorer.ErrInvalidCode.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// CreateChallenge save a new login challenge and remove the expired ones of the user
+func (r *TwoFactorRepositoryImpl) CreateChallenge(ctx context.Context, challenge entity.LoginChallenge) (*entity.LoginChallenge, int, error) {
+	challenge.CreatedAt = time.Now().UnixMilli()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_challenges WHERE user_id = $1 AND expires_at <= $2", challenge.UserID, challenge.CreatedAt)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		"INSERT INTO login_challenges (user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		challenge.UserID, challenge.TokenHash, challenge.ExpiresAt, challenge.CreatedAt).Scan(&challenge.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &challenge, http.StatusCreated, nil
+}
+
+// FindChallenge return an unexpired challenge with attempts left, without using one
+func (r *TwoFactorRepositoryImpl) FindChallenge(ctx context.Context, tokenHash string, maxAttempts int) (*entity.LoginChallenge, int, error) {
+	var c entity.LoginChallenge
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, token_hash, attempts, expires_at, created_at FROM login_challenges
+		WHERE token_hash = $1 AND expires_at > $2 AND attempts < $3`,
+		tokenHash, time.Now().UnixMilli(), maxAttempts).
+		Scan(&c.ID, &c.UserID, &c.TokenHash, &c.Attempts, &c.ExpiresAt, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusUnauthorized, errors.Wrap(errorer.ErrInvalidToken, errorer.ErrInvalidToken.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &c, http.StatusOK, nil
+}
+
+// AttemptChallenge use one attempt of an unexpired challenge, it fail once maxAttempts were used
+func (r *TwoFactorRepositoryImpl) AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (*entity.LoginChallenge, int, error) {
+	var c entity.LoginChallenge
+
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > $2 AND attempts < $3
+		RETURNING id, user_id, token_hash, attempts, expires_at, created_at`,
+		tokenHash, time.Now().UnixMilli(), maxAttempts).
+		Scan(&c.ID, &c.UserID, &c.TokenHash, &c.Attempts, &c.ExpiresAt, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusUnauthorized, errors.Wrap(errorer.ErrInvalidToken, errorer.ErrInvalidToken.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &c, http.StatusOK, nil
+}
+
+func (r *TwoFactorRepositoryImpl) DeleteChallenge(ctx context.Context, id int64) (int, error) {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_challenges WHERE id = $1", id)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return http.StatusOK, nil
+}
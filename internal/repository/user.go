@@ -18,6 +18,8 @@ type UserRepository interface {
 	FindByPhone(ctx context.Context, phone string) (*entity.User, int, error)
 	FindByID(ctx context.Context, id int64) (*entity.User, int, error)
 	UpdateByID(ctx context.Context, user entity.User) (*entity.User, int, error)
+	FindProfile(ctx context.Context, id int64, viewerID int64) (*entity.UserProfile, int, error)
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) (int, error)
 }
 
 func NewUserRepository(logger zerolog.Logger, db *sql.DB) UserRepository {
@@ -101,3 +103,65 @@ func (r *UserRepositoryImpl) UpdateByID(ctx context.Context, user entity.User) (
 
 	return &user, http.StatusOK, nil
 }
+
+// FindProfile load a user with the number of their posts visible to the viewer and how they relate to the viewer
+func (r *UserRepositoryImpl) FindProfile(ctx context.Context, id int64, viewerID int64) (*entity.UserProfile, int, error) {
+	var profile entity.UserProfile
+
+	row := r.db.QueryRowContext(ctx, `
+		SELECT
+			u.id,
+			u.email,
+			u.phone,
+			u.name,
+			u.friend_count,
+			COALESCE(u.image_url, ''),
+			u.created_at,
+			u.updated_at,
+			(SELECT COUNT(*) FROM posts p WHERE p.user_id = u.id AND `+visiblePostCondition("$2")+`),
+			EXISTS (
+				SELECT 1 FROM friendships f
+				WHERE ((f.user_id = u.id AND f.added_by = $2) OR (f.user_id = $2 AND f.added_by = u.id))
+				AND f.status = $3
+			),
+			(SELECT COUNT(*) FROM ((`+friendIDsQuery("u.id")+`) INTERSECT (`+friendIDsQuery("$2")+`)) mutual)
+		FROM users u
+		WHERE u.id = $1`, id, viewerID, entity.FriendshipStatusAccepted)
+	err := row.Scan(
+		&profile.ID,
+		&profile.Email,
+		&profile.Phone,
+		&profile.Name,
+		&profile.FriendCount,
+		&profile.ImageUrl,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+		&profile.PostCount,
+		&profile.IsFriend,
+		&profile.MutualFriendCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+	return &profile, http.StatusOK, nil
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, id int64, passwordHash string) (int, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET password = $1, updated_at = $2 WHERE id = $3", passwordHash, time.Now().UnixMilli(), id)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	row, err := res.RowsAffected()
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalDatabase, err.Error())
+	}
+
+	if row == 0 {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+func (s *service) BlockUser(ctx context.Context, payload request.BlockUser) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	blockedID, err := strconv.Atoi(payload.BlockedID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	if int64(blockedID) == payload.UserID {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "can not block yourself")
+	}
+
+	return s.blockRepo.Block(ctx, payload.UserID, int64(blockedID))
+}
+
+func (s *service) UnblockUser(ctx context.Context, payload request.UnblockUser) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	blockedID, err := strconv.Atoi(payload.BlockedID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	return s.blockRepo.Unblock(ctx, payload.UserID, int64(blockedID))
+}
+
+func (s *service) FindAllBlocks(ctx context.Context, filter request.FindAllBlocks) ([]response.Block, *common.Meta, int, error) {
+	ent, meta, code, err := s.blockRepo.FindAll(ctx, entity.FindAllBlockRequest{
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+		UserID: filter.UserID,
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ret := make([]response.Block, len(ent))
+	for i, e := range ent {
+		ret[i] = response.Block{
+			User:      toFriendResponse(e.User),
+			CreatedAt: common.UnixMilliToISO8601(e.CreatedAt),
+		}
+	}
+	return ret, meta, code, nil
+}
+
+// checkNotBlocked return forbidden when the user blocked, or was blocked by, any of the others
+func (s *service) checkNotBlocked(ctx context.Context, userID int64, others ...int64) (int, error) {
+	for _, other := range others {
+		if other == userID {
+			continue
+		}
+		blocked, code, err := s.blockRepo.IsBlocked(ctx, userID, other)
+		if err != nil {
+			return code, err
+		}
+		if blocked {
+			return http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, "user is blocked")
+		}
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CreateConversation start a conversation with a friend, or return the one already started
+func (s *service) CreateConversation(ctx context.Context, payload request.CreateConversation) (*response.Conversation, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	friendID, err := strconv.Atoi(payload.FriendID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	if int64(friendID) == payload.UserID {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "can not message yourself")
+	}
+
+	friend, code, err := s.userRepo.FindByID(ctx, int64(friendID))
+	if err != nil {
+		return nil, code, err
+	}
+
+	conv, code, err := s.conversationRepo.FindOrCreate(ctx, payload.UserID, friend.ID)
+	if err != nil {
+		return nil, code, err
+	}
+	conv.Friend = *friend
+
+	ret := toConversationResponse(*conv)
+	return &ret, http.StatusOK, nil
+}
+
+func (s *service) FindAllConversations(ctx context.Context, filter request.FindAllConversations) ([]response.Conversation, *common.Meta, int, error) {
+	ent, meta, code, err := s.conversationRepo.FindAll(ctx, entity.FindAllConversationRequest{
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+		UserID: filter.UserID,
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	conversations := make([]response.Conversation, len(ent))
+	for i, e := range ent {
+		conversations[i] = toConversationResponse(e)
+	}
+	return conversations, meta, code, nil
+}
+
+// SendMessage send a message in a conversation of the user, both participants must still be friends
+func (s *service) SendMessage(ctx context.Context, payload request.SendMessage) (*response.Message, int, error) {
+	payload.Content = strings.TrimSpace(payload.Content)
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	conv, code, err := s.findConversation(ctx, payload.ConversationID, payload.UserID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	// unfriended users keep their history but can no longer message each other
+	recipientID := conv.OtherParticipant(payload.UserID)
+	friend, code, err := s.friendshipRepo.IsFriend(ctx, payload.UserID, recipientID)
+	if err != nil {
+		return nil, code, err
+	}
+	if !friend {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "user is not friend")
+	}
+
+	msg, code, err := s.conversationRepo.CreateMessage(ctx, entity.Message{
+		ConversationID: conv.ID,
+		SenderID:       payload.UserID,
+		Content:        payload.Content,
+	})
+	if err != nil {
+		return nil, code, err
+	}
+
+	ret := toMessageResponse(*msg)
+	s.eventRepo.Publish(recipientID, entity.EventTypeMessageCreated, ret)
+	return &ret, code, nil
+}
+
+// FindAllMessages list messages of a conversation of the user newest first using cursor pagination
+func (s *service) FindAllMessages(ctx context.Context, payload request.FindAllMessages) ([]response.Message, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	conv, code, err := s.findConversation(ctx, payload.ConversationID, payload.UserID)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	filter := entity.FindAllMessageRequest{
+		ConversationID: conv.ID,
+		Limit:          payload.Limit,
+	}
+	if payload.Cursor != "" {
+		createdAt, id, err := common.DecodeCursor(payload.Cursor)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, err.Error())
+		}
+		filter.CursorCreatedAt = createdAt
+		filter.CursorID = id
+	}
+
+	ent, code, err := s.conversationRepo.FindMessages(ctx, filter)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	messages := make([]response.Message, len(ent))
+	for i, e := range ent {
+		messages[i] = toMessageResponse(e)
+	}
+
+	meta := common.Meta{
+		Limit: payload.Limit,
+		Total: len(messages),
+	}
+	if len(ent) > 0 && len(ent) == payload.Limit {
+		last := ent[len(ent)-1]
+		meta.NextCursor = common.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return messages, &meta, code, nil
+}
+
+// ReadConversation mark the messages the user received in a conversation as read and tell the sender
+func (s *service) ReadConversation(ctx context.Context, payload request.ReadConversation) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	conv, code, err := s.findConversation(ctx, payload.ConversationID, payload.UserID)
+	if err != nil {
+		return code, err
+	}
+
+	read, code, err := s.conversationRepo.MarkRead(ctx, conv.ID, payload.UserID)
+	if err != nil {
+		return code, err
+	}
+
+	if read > 0 {
+		s.eventRepo.Publish(conv.OtherParticipant(payload.UserID), entity.EventTypeMessageRead, response.MessageReadEvent{
+			ConversationID: strconv.Itoa(int(conv.ID)),
+			UserID:         strconv.Itoa(int(payload.UserID)),
+		})
+	}
+	return code, nil
+}
+
+// findConversation load a conversation by its string id and check the user takes part in it
+func (s *service) findConversation(ctx context.Context, conversationID string, userID int64) (*entity.Conversation, int, error) {
+	id, err := strconv.Atoi(conversationID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	conv, code, err := s.conversationRepo.FindByID(ctx, int64(id))
+	if err != nil {
+		return nil, code, err
+	}
+
+	if !conv.HasParticipant(userID) {
+		return nil, http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, errorer.ErrForbidden.Error())
+	}
+	return conv, http.StatusOK, nil
+}
+
+func toConversationResponse(e entity.Conversation) response.Conversation {
+	ret := response.Conversation{
+		ConversationID: strconv.Itoa(int(e.ID)),
+		User: response.ConversationUser{
+			UserID:   strconv.Itoa(int(e.Friend.ID)),
+			Name:     e.Friend.Name,
+			ImageURL: e.Friend.ImageUrl,
+		},
+		UnreadCount: e.UnreadCount,
+		CreatedAt:   common.UnixMilliToISO8601(e.CreatedAt),
+	}
+	if e.LastMessage != nil {
+		msg := toMessageResponse(*e.LastMessage)
+		ret.LastMessage = &msg
+	}
+	return ret
+}
+
+func toMessageResponse(e entity.Message) response.Message {
+	ret := response.Message{
+		MessageID:      strconv.Itoa(int(e.ID)),
+		ConversationID: strconv.Itoa(int(e.ConversationID)),
+		SenderID:       strconv.Itoa(int(e.SenderID)),
+		Content:        e.Content,
+		Read:           e.ReadAt != nil,
+		CreatedAt:      common.UnixMilliToISO8601(e.CreatedAt),
+	}
+	if e.ReadAt != nil {
+		ret.ReadAt = common.UnixMilliToISO8601(*e.ReadAt)
+	}
+	return ret
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/response"
+	"socialapp/internal/repository"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// EventStream is an open subscription to the events of one user
+type EventStream struct {
+	// Replay holds the events published since the last event id the client resumed from,
+	// led by a reset event when some of them were lost and the client should refetch
+	Replay []response.Event
+	Events <-chan entity.Event
+
+	sub       *repository.EventSubscription
+	eventRepo repository.EventRepository
+}
+
+// Close end the subscription, it is safe to call more than once
+func (es *EventStream) Close() {
+	es.eventRepo.Unsubscribe(es.sub)
+}
+
+// SubscribeEvents open an event stream for the user, resuming after lastEventID when it is set
+func (s *service) SubscribeEvents(ctx context.Context, userID int64, lastEventID string) (*EventStream, int, error) {
+	var lastID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+		}
+		lastID = id
+	}
+
+	sub, missed, reset := s.eventRepo.Subscribe(userID, lastID)
+	stream := &EventStream{
+		Replay:    []response.Event{},
+		Events:    sub.Events,
+		sub:       sub,
+		eventRepo: s.eventRepo,
+	}
+	if reset {
+		stream.Replay = append(stream.Replay, response.Event{Type: entity.EventTypeReset})
+	}
+	for _, ev := range missed {
+		stream.Replay = append(stream.Replay, stream.ToResponse(ev))
+	}
+	return stream, http.StatusOK, nil
+}
+
+// ToResponse convert an event received from Events for delivery to the client
+func (es *EventStream) ToResponse(ev entity.Event) response.Event {
+	return response.Event{
+		ID:        strconv.FormatInt(ev.ID, 10),
+		Type:      ev.Type,
+		Data:      ev.Data,
+		CreatedAt: common.UnixMilliToISO8601(ev.CreatedAt),
+	}
+}
+
+// publishToFriends deliver an event to every friend of the user
+func (s *service) publishToFriends(ctx context.Context, userID int64, eventType string, data interface{}) {
+	ids, _, err := s.friendshipRepo.FindFriendIDs(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int64("userID", userID).Str("type", eventType).Msg("failed to publish event to friends")
+		return
+	}
+
+	for _, id := range ids {
+		s.eventRepo.Publish(id, eventType, data)
+	}
+}
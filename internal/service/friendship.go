@@ -47,6 +47,79 @@ func (s *service) FindAllFriendships(ctx context.Context, filter request.FindAll
 	return ret, meta, code, nil
 }
 
+// FindMutualFriends list the friends the user has in common with another user
+func (s *service) FindMutualFriends(ctx context.Context, filter request.FindMutualFriends) ([]response.FindAllFriendships, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&filter)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	otherID, err := strconv.Atoi(filter.OtherID)
+	if err != nil {
+		return nil, nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	if int64(otherID) == filter.UserID {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "can not list mutual friends with yourself")
+	}
+
+	_, code, err := s.userRepo.FindByID(ctx, int64(otherID))
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ent, meta, code, err := s.friendshipRepo.FindMutual(ctx, entity.FindMutualFriendRequest{
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+		UserID:  filter.UserID,
+		OtherID: int64(otherID),
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ret := make([]response.FindAllFriendships, len(ent))
+	for i, e := range ent {
+		ret[i] = toFriendResponse(e)
+	}
+	return ret, meta, code, nil
+}
+
+// FindFriendSuggestions list people the user may know, most mutual friends first
+func (s *service) FindFriendSuggestions(ctx context.Context, filter request.FindFriendSuggestions) ([]response.FriendSuggestion, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&filter)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	ent, meta, code, err := s.friendshipRepo.FindSuggestions(ctx, entity.FindFriendSuggestionRequest{
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+		UserID: filter.UserID,
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ret := make([]response.FriendSuggestion, len(ent))
+	for i, e := range ent {
+		ret[i] = response.FriendSuggestion{
+			FindAllFriendships: toFriendResponse(e.User),
+			MutualFriendCount:  e.MutualFriendCount,
+		}
+	}
+	return ret, meta, code, nil
+}
+
+func toFriendResponse(e entity.User) response.FindAllFriendships {
+	return response.FindAllFriendships{
+		ID:          strconv.Itoa(int(e.ID)),
+		Name:        e.Name,
+		ImageUrl:    e.ImageUrl,
+		FriendCount: e.FriendCount,
+		CreatedAt:   common.UnixMilliToISO8601(e.CreatedAt),
+	}
+}
+
 func (s *service) CreateFriendship(ctx context.Context, payload request.CreateFriendship) (int, error) {
 	err := validator.ValidateStruct(&payload)
 	if err != nil {
@@ -66,7 +139,22 @@ func (s *service) CreateFriendship(ctx context.Context, payload request.CreateFr
 		return http.StatusBadRequest, errors.Wrap(errors.New("can not add yourself"), "can not add yourself")
 	}
 
-	return s.friendshipRepo.CreateFriendship(ctx, int64(userID), addedBy)
+	frd, code, err := s.friendshipRepo.CreateFriendship(ctx, int64(userID), addedBy)
+	if err != nil {
+		return code, err
+	}
+
+	s.notify(ctx, entity.Notification{
+		UserID:      frd.UserID,
+		ActorID:     frd.AddedBy,
+		Type:        entity.NotificationTypeFriendRequest,
+		ReferenceID: frd.ID,
+	})
+	s.eventRepo.Publish(frd.UserID, entity.EventTypeFriendRequestCreated, response.FriendRequestEvent{
+		RequestID: strconv.Itoa(int(frd.ID)),
+		UserID:    strconv.Itoa(int(frd.AddedBy)),
+	})
+	return code, nil
 }
 
 func (s *service) DeleteFriendship(ctx context.Context, payload request.DeleteFriendship) (int, error) {
@@ -87,3 +175,94 @@ func (s *service) DeleteFriendship(ctx context.Context, payload request.DeleteFr
 
 	return s.friendshipRepo.DeleteFriendship(ctx, int64(friend1), friend2)
 }
+
+func (s *service) FindAllFriendRequests(ctx context.Context, filter request.FindAllFriendRequests) ([]response.FriendRequest, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&filter)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	ent, meta, code, err := s.friendshipRepo.FindRequests(ctx, entity.FindAllFriendRequestRequest{
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+		Incoming: filter.Type == "incoming",
+		UserID:   filter.UserID,
+	})
+
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ret := make([]response.FriendRequest, len(ent))
+	for i, e := range ent {
+		ret[i] = response.FriendRequest{
+			ID: strconv.Itoa(int(e.ID)),
+			User: response.FindAllFriendships{
+				ID:          strconv.Itoa(int(e.User.ID)),
+				Name:        e.User.Name,
+				ImageUrl:    e.User.ImageUrl,
+				FriendCount: e.User.FriendCount,
+				CreatedAt:   common.UnixMilliToISO8601(e.User.CreatedAt),
+			},
+			CreatedAt: common.UnixMilliToISO8601(e.CreatedAt),
+		}
+	}
+	return ret, meta, code, nil
+}
+
+func (s *service) AcceptFriendRequest(ctx context.Context, payload request.FriendRequestAction) (int, error) {
+	id, code, err := parseFriendRequestAction(payload)
+	if err != nil {
+		return code, err
+	}
+
+	frd, code, err := s.friendshipRepo.AcceptFriendship(ctx, id, payload.UserID)
+	if err != nil {
+		return code, err
+	}
+
+	s.notify(ctx, entity.Notification{
+		UserID:      frd.AddedBy,
+		ActorID:     frd.UserID,
+		Type:        entity.NotificationTypeFriendAccepted,
+		ReferenceID: frd.ID,
+	})
+	s.eventRepo.Publish(frd.AddedBy, entity.EventTypeFriendshipCreated, response.FriendshipEvent{
+		UserID: strconv.Itoa(int(frd.UserID)),
+	})
+	s.eventRepo.Publish(frd.UserID, entity.EventTypeFriendshipCreated, response.FriendshipEvent{
+		UserID: strconv.Itoa(int(frd.AddedBy)),
+	})
+	return code, nil
+}
+
+func (s *service) RejectFriendRequest(ctx context.Context, payload request.FriendRequestAction) (int, error) {
+	id, code, err := parseFriendRequestAction(payload)
+	if err != nil {
+		return code, err
+	}
+
+	return s.friendshipRepo.RejectFriendship(ctx, id, payload.UserID)
+}
+
+func (s *service) CancelFriendRequest(ctx context.Context, payload request.FriendRequestAction) (int, error) {
+	id, code, err := parseFriendRequestAction(payload)
+	if err != nil {
+		return code, err
+	}
+
+	return s.friendshipRepo.CancelFriendship(ctx, id, payload.UserID)
+}
+
+func parseFriendRequestAction(payload request.FriendRequestAction) (int64, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return 0, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	id, err := strconv.Atoi(payload.RequestID)
+	if err != nil || id == 0 || payload.UserID == 0 {
+		return 0, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return int64(id), http.StatusOK, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/imaging"
+	"socialapp/internal/helper/token"
+	"socialapp/internal/model/response"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// imageVariantSizes are the longest side in pixels of the resized copies made for every upload
+var imageVariantSizes = []int{64, 256, 1024}
+
+// imageJob hold the uploaded bytes rather than the decoded image, which can take tens of MB
+type imageJob struct {
+	data   []byte
+	base   string
+	format string
+}
+
+func (s *service) UploadImage(ctx context.Context, file *multipart.FileHeader) (*response.Image, int, error) {
+	s.log.Debug().Msgf("file size: %d", file.Size)
+	if file.Size >= 2_000_000 || file.Size <= 10_000 {
+		return nil, http.StatusBadRequest, errors.Wrap(errors.New("file size must between 10KB and 2MB"), "file size must between 10KB and 2MB")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, 2_000_000))
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, err.Error())
+	}
+
+	// the type is taken from the content, never from the file name
+	img, format, err := imaging.Decode(data, s.cfg.MaxImageDimension)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(err, err.Error())
+	}
+	img = imaging.Orient(img, imaging.Orientation(data))
+
+	name, err := token.Generate(8)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	job := imageJob{
+		data:   data,
+		base:   fmt.Sprintf("%d-%s", time.Now().UnixMilli(), name),
+		format: imaging.OutputFormat(format),
+	}
+
+	// the original is re-encoded from its pixels so EXIF and GPS metadata are not stored
+	imageUrl, code, err := s.uploadImage(ctx, img, job.base+"."+imaging.Extension(job.format), job.format)
+	if err != nil {
+		return nil, code, err
+	}
+
+	ret := &response.Image{
+		ImageUrl:      imageUrl,
+		VariantStatus: response.ImageVariantsPending,
+	}
+
+	if s.imageJobs != nil {
+		select {
+		case s.imageJobs <- job:
+			// the variants may still fail in the worker, so no url is given for them yet
+			return ret, http.StatusOK, nil
+		default:
+			// queue is full, fall back to resizing within the request
+		}
+	}
+
+	code, err = s.uploadImageVariants(ctx, img, job.base, job.format)
+	if err != nil {
+		return nil, code, err
+	}
+
+	ret.VariantStatus = response.ImageVariantsReady
+	ret.Variants = make(map[string]string, len(imageVariantSizes))
+	for _, size := range imageVariantSizes {
+		ret.Variants[strconv.Itoa(size)] = s.blobRepo.URL(variantKey(job.base, size, job.format))
+	}
+	return ret, http.StatusOK, nil
+}
+
+// imageWorker resize images queued by UploadImage when background processing is enabled
+func (s *service) imageWorker() {
+	for job := range s.imageJobs {
+		img, _, err := imaging.Decode(job.data, s.cfg.MaxImageDimension)
+		if err != nil {
+			s.log.Error().Err(err).Str("image", job.base).Msg("failed to decode queued image")
+			continue
+		}
+		img = imaging.Orient(img, imaging.Orientation(job.data))
+
+		_, err = s.uploadImageVariants(context.Background(), img, job.base, job.format)
+		if err != nil {
+			s.log.Error().Err(err).Str("image", job.base).Msg("failed to create image variants")
+		}
+	}
+}
+
+func (s *service) uploadImageVariants(ctx context.Context, img image.Image, base string, format string) (int, error) {
+	for _, size := range imageVariantSizes {
+		variant := imaging.Resize(img, size)
+		_, code, err := s.uploadImage(ctx, variant, variantKey(base, size, format), format)
+		if err != nil {
+			return code, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+func (s *service) uploadImage(ctx context.Context, img image.Image, key string, format string) (string, int, error) {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format); err != nil {
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	return s.blobRepo.UploadFile(ctx, key, &buf, imaging.ContentType(format))
+}
+
+func variantKey(base string, size int, format string) string {
+	return fmt.Sprintf("%s_%d.%s", base, size, imaging.Extension(format))
+}
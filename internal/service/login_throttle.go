@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/model/entity"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// loginThrottle is the backoff policy of a throttle key: no delay for the first failures,
+// then a delay doubling on every failure and a lockout once too many failures are recorded
+type loginThrottle struct {
+	freeFailures    int
+	lockoutFailures int
+}
+
+var (
+	credentialThrottle = loginThrottle{freeFailures: 3, lockoutFailures: 10}
+	// ipThrottle is looser since many users may share an ip behind a NAT
+	ipThrottle = loginThrottle{freeFailures: 20, lockoutFailures: 100}
+)
+
+const (
+	loginBackoffBase = time.Second
+	loginBackoffMax  = 5 * time.Minute
+)
+
+func (t loginThrottle) backoff(lockout time.Duration) func(failures int) time.Duration {
+	return func(failures int) time.Duration {
+		if failures >= t.lockoutFailures {
+			return lockout
+		}
+		if failures < t.freeFailures {
+			return 0
+		}
+
+		delay := loginBackoffMax
+		if shift := failures - t.freeFailures; shift < 20 {
+			delay = min(loginBackoffBase<<shift, loginBackoffMax)
+		}
+		return delay
+	}
+}
+
+type throttleKey struct {
+	key      string
+	throttle loginThrottle
+}
+
+// loginKeys return the keys counting the failed logins of a credential and of an ip
+func loginKeys(credentialType string, credentialValue string, ip string) []throttleKey {
+	return []throttleKey{
+		{key: "credential:" + credentialType + ":" + strings.ToLower(credentialValue), throttle: credentialThrottle},
+		{key: "ip:" + ip, throttle: ipThrottle},
+	}
+}
+
+// twoFactorKeys return the keys counting the failed second factors of a user and of an ip
+func twoFactorKeys(userID int64, ip string) []throttleKey {
+	return []throttleKey{
+		{key: "two-factor:" + strconv.FormatInt(userID, 10), throttle: credentialThrottle},
+		{key: "ip:" + ip, throttle: ipThrottle},
+	}
+}
+
+// passwordResetKeys return the keys counting the password reset requests of a credential and of an ip
+func passwordResetKeys(credentialType string, credentialValue string, ip string) []throttleKey {
+	return []throttleKey{
+		{key: "password-reset:" + credentialType + ":" + strings.ToLower(credentialValue), throttle: credentialThrottle},
+		{key: "password-reset-ip:" + ip, throttle: ipThrottle},
+	}
+}
+
+// reserveLogin count the login as a failure on every key before it is checked, so concurrent
+// attempts cannot pass the throttle together. The login is refused while any of keys is blocked.
+func (s *service) reserveLogin(ctx context.Context, event entity.SecurityEvent, keys []throttleKey) ([]*entity.LoginAttempt, int, error) {
+	attempts := make([]*entity.LoginAttempt, 0, len(keys))
+	for i, k := range keys {
+		attempt, allowed, code, err := s.loginAttemptRepo.Reserve(ctx, k.key, s.cfg.LoginFailureWindow, k.throttle.backoff(s.cfg.LoginLockout))
+		if err == nil && allowed {
+			attempts = append(attempts, attempt)
+			continue
+		}
+
+		// the keys reserved so far did not get a real attempt
+		s.releaseLogins(ctx, keys[:i])
+		if err != nil {
+			return nil, code, err
+		}
+
+		// only the first refusal of a block is audited, so hammering a blocked key cannot grow the log
+		if attempt.ThrottledUntil != attempt.BlockedUntil {
+			event.EventType = entity.SecurityEventLoginThrottled
+			s.recordSecurityEvent(ctx, event)
+		}
+		return nil, http.StatusTooManyRequests, errors.Wrap(errorer.ErrTooManyAttempts, errorer.ErrTooManyAttempts.Error())
+	}
+	return attempts, http.StatusOK, nil
+}
+
+// recordLoginFailure audit a failed login, its failure was already counted by reserveLogin
+func (s *service) recordLoginFailure(ctx context.Context, event entity.SecurityEvent, keys []throttleKey, attempts []*entity.LoginAttempt) {
+	s.recordSecurityEvent(ctx, event)
+
+	for i, k := range keys {
+		if attempts[i].Failures == k.throttle.lockoutFailures {
+			locked := event
+			locked.EventType = entity.SecurityEventLoginLocked
+			s.recordSecurityEvent(ctx, locked)
+		}
+	}
+}
+
+// resetLoginFailures forget the failures of keys after a successful login. The ip keys only
+// get their reserved attempt back so one valid account cannot be used to clear them.
+func (s *service) resetLoginFailures(ctx context.Context, keys []throttleKey) {
+	for _, k := range keys {
+		if strings.HasPrefix(k.key, "ip:") {
+			s.releaseLogin(ctx, k)
+			continue
+		}
+		if _, err := s.loginAttemptRepo.Reset(ctx, k.key); err != nil {
+			s.log.Error().Err(err).Str("key", k.key).Msg("reset login failures error")
+		}
+	}
+}
+
+// releaseLogins take back the attempts reserved on keys when the login ended without
+// a credential check
+func (s *service) releaseLogins(ctx context.Context, keys []throttleKey) {
+	for _, k := range keys {
+		s.releaseLogin(ctx, k)
+	}
+}
+
+// releaseLogin take back the attempt reserved on k, errors are only logged
+func (s *service) releaseLogin(ctx context.Context, k throttleKey) {
+	if _, err := s.loginAttemptRepo.Release(ctx, k.key, k.throttle.backoff(s.cfg.LoginLockout)); err != nil {
+		s.log.Error().Err(err).Str("key", k.key).Msg("release login attempt error")
+	}
+}
+
+func (s *service) recordSecurityEvent(ctx context.Context, event entity.SecurityEvent) {
+	if _, err := s.auditRepo.Create(ctx, event); err != nil {
+		s.log.Error().Err(err).Str("event", event.EventType).Msg("record security event error")
+	}
+}
+
+// CleanupSecurityEvents delete the security events older than the audit retention
+func (s *service) CleanupSecurityEvents(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.cfg.AuditRetention).UnixMilli()
+	deleted, code, err := s.auditRepo.DeleteOlderThan(ctx, before)
+	if err != nil {
+		return code, err
+	}
+
+	s.log.Info().Int64("deleted", deleted).Msg("security events cleaned up")
+	return code, nil
+}
+
+// CleanupLoginAttempts delete the failed login counts older than the failure window
+func (s *service) CleanupLoginAttempts(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.cfg.LoginFailureWindow).UnixMilli()
+	deleted, code, err := s.loginAttemptRepo.DeleteExpired(ctx, before)
+	if err != nil {
+		return code, err
+	}
+
+	s.log.Info().Int64("deleted", deleted).Msg("login attempts cleaned up")
+	return code, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	const lockout = 15 * time.Minute
+
+	tests := []struct {
+		name     string
+		throttle loginThrottle
+		failures int
+		want     time.Duration
+	}{
+		{"credential no failure", credentialThrottle, 0, 0},
+		{"credential last free failure", credentialThrottle, 2, 0},
+		{"credential first delay", credentialThrottle, 3, time.Second},
+		{"credential delay doubles", credentialThrottle, 4, 2 * time.Second},
+		{"credential before lockout", credentialThrottle, 9, 64 * time.Second},
+		{"credential lockout", credentialThrottle, 10, lockout},
+		{"credential past lockout", credentialThrottle, 25, lockout},
+		{"ip last free failure", ipThrottle, 19, 0},
+		{"ip first delay", ipThrottle, 20, time.Second},
+		{"ip delay capped", ipThrottle, 29, loginBackoffMax},
+		{"ip large shift capped", ipThrottle, 99, loginBackoffMax},
+		{"ip lockout", ipThrottle, 100, lockout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.throttle.backoff(lockout)(tt.failures); got != tt.want {
+				t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
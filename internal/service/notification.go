@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// notify store a notification for the recipient. Failures are only logged so they never
+// fail the action that triggered them.
+func (s *service) notify(ctx context.Context, ent entity.Notification) {
+	if ent.UserID == ent.ActorID {
+		return
+	}
+
+	_, _, err := s.notificationRepo.Create(ctx, ent)
+	if err != nil {
+		s.log.Error().Err(err).Int64("userID", ent.UserID).Str("type", ent.Type).Msg("failed to create notification")
+	}
+}
+
+func (s *service) FindAllNotifications(ctx context.Context, filter request.FindAllNotifications) (*response.Notifications, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&filter)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	ent, meta, code, err := s.notificationRepo.FindAll(ctx, entity.FindAllNotificationRequest{
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+		UnreadOnly: filter.UnreadOnly,
+		UserID:     filter.UserID,
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	unread, code, err := s.notificationRepo.CountUnread(ctx, filter.UserID)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ret := &response.Notifications{
+		Notifications: make([]response.Notification, len(ent)),
+		UnreadCount:   unread,
+	}
+	for i, e := range ent {
+		ret.Notifications[i] = response.Notification{
+			ID:   strconv.Itoa(int(e.ID)),
+			Type: e.Type,
+			Actor: response.NotificationActor{
+				UserID:   strconv.Itoa(int(e.Actor.ID)),
+				Name:     e.Actor.Name,
+				ImageURL: e.Actor.ImageUrl,
+			},
+			ReferenceID: strconv.Itoa(int(e.ReferenceID)),
+			Read:        e.ReadAt != nil,
+			CreatedAt:   common.UnixMilliToISO8601(e.CreatedAt),
+		}
+	}
+	return ret, meta, http.StatusOK, nil
+}
+
+func (s *service) ReadNotification(ctx context.Context, payload request.ReadNotification) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	id, err := strconv.Atoi(payload.NotificationID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	return s.notificationRepo.MarkRead(ctx, int64(id), payload.UserID)
+}
+
+func (s *service) ReadAllNotifications(ctx context.Context, userID int64) (int, error) {
+	return s.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+// CleanupNotifications delete notifications older than the configured retention
+func (s *service) CleanupNotifications(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.cfg.NotificationRetention).UnixMilli()
+	deleted, code, err := s.notificationRepo.DeleteOlderThan(ctx, before)
+	if err != nil {
+		return code, err
+	}
+
+	s.log.Info().Int64("deleted", deleted).Msg("notifications cleaned up")
+	return code, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/token"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword replace the password of the user and sign out every other session,
+// the session making the request stays signed in
+func (s *service) ChangePassword(ctx context.Context, payload request.ChangePassword) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	user, code, err := s.userRepo.FindByID(ctx, payload.UserID)
+	if err != nil {
+		return code, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.CurrentPassword))
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrWrongPassword, errorer.ErrWrongPassword.Error())
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), s.cfg.Salt)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	code, err = s.userRepo.UpdatePassword(ctx, payload.UserID, string(hashedPassword))
+	if err != nil {
+		return code, err
+	}
+
+	return s.sessionRepo.RevokeAllExcept(ctx, payload.UserID, payload.SessionID)
+}
+
+// ForgotPassword send a reset token to the email or phone of the user. It succeed even when
+// no user has the credential so the endpoint cannot be used to find registered accounts.
+func (s *service) ForgotPassword(ctx context.Context, payload request.ForgotPassword) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	code, err := validateCredential(payload.CredentialType, payload.CredentialValue)
+	if err != nil {
+		return code, err
+	}
+
+	// every request is counted and never forgiven, each one sends a message to the credential
+	event := entity.SecurityEvent{
+		CredentialType:  payload.CredentialType,
+		CredentialValue: payload.CredentialValue,
+		IPAddress:       payload.IP,
+	}
+	_, code, err = s.reserveLogin(ctx, event, passwordResetKeys(payload.CredentialType, payload.CredentialValue, payload.IP))
+	if err != nil {
+		return code, err
+	}
+
+	var user *entity.User
+	if payload.CredentialType == entity.CredentialTypeEmail {
+		user, code, err = s.userRepo.FindByEmail(ctx, payload.CredentialValue)
+	} else {
+		user, code, err = s.userRepo.FindByPhone(ctx, payload.CredentialValue)
+	}
+	if err != nil {
+		if code == http.StatusNotFound {
+			return http.StatusOK, nil
+		}
+		return code, err
+	}
+
+	resetToken, err := token.Generate(32)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	_, code, err = s.resetRepo.Create(ctx, entity.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: token.Hash(resetToken),
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL).UnixMilli(),
+	})
+	if err != nil {
+		return code, err
+	}
+
+	code, err = s.messageSender.Send(ctx, entity.OutgoingMessage{
+		Channel: payload.CredentialType,
+		To:      payload.CredentialValue,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use this token to reset your password: %s\nIt expires in %s. If you did not ask for it, ignore this message.",
+			resetToken, s.cfg.PasswordResetTTL),
+	})
+	if err != nil {
+		return code, err
+	}
+	return http.StatusOK, nil
+}
+
+// ResetPassword set a new password with a token sent by ForgotPassword and sign out every session of the user
+func (s *service) ResetPassword(ctx context.Context, payload request.ResetPassword) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	// the token is used before hashing so invalid tokens cost no bcrypt work
+	userID, code, err := s.resetRepo.Consume(ctx, token.Hash(payload.Token))
+	if err != nil {
+		return code, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), s.cfg.Salt)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	code, err = s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword))
+	if err != nil {
+		return code, err
+	}
+
+	return s.sessionRepo.RevokeAllByUserID(ctx, userID)
+}
@@ -2,121 +2,192 @@ package service
 
 import (
 	"context"
+	"html"
 	"net/http"
 	"socialapp/internal/helper/common"
 	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/sanitizer"
 	"socialapp/internal/helper/validator"
 	"socialapp/internal/model/entity"
 	"socialapp/internal/model/request"
 	"socialapp/internal/model/response"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// commentPreviewLimit is the number of latest comments embedded in every post
+	commentPreviewLimit = 5
+	// maxCommentDepth is the number of nesting levels of a comment thread, top-level comments included
+	maxCommentDepth = 3
+	// maxTagLength is the longest tag in characters, matching the tags.name column
+	maxTagLength = 64
+)
+
 func (s *service) CreatePost(ctx context.Context, payload request.CreatePost) (int, error) {
 	err := validator.ValidateStruct(&payload)
 	if err != nil {
 		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
 	}
-	for _, tag := range payload.Tags {
-		if tag == "" {
-			return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
-		}
+	tags, ok := normalizeTags(payload.Tags)
+	if !ok {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+
+	contentHtml, contentText, code, err := sanitizePostContent(payload.ContentHtml)
+	if err != nil {
+		return code, err
+	}
+
+	visibility := payload.Visibility
+	if visibility == "" {
+		visibility = entity.PostVisibilityPublic
+	}
+	audience, code, err := parsePostAudience(visibility, payload.Audience, payload.UserID)
+	if err != nil {
+		return code, err
 	}
 
 	// insert post
-	return s.postRepo.CreatePost(ctx, entity.Post{
-		ContentHtml: payload.ContentHtml,
+	post, code, err := s.postRepo.CreatePost(ctx, entity.Post{
+		ContentHtml: contentHtml,
+		ContentText: contentText,
 		UserID:      payload.UserID,
-		Tags:        strings.Join(payload.Tags, ","),
+		Tags:        tags,
+		Visibility:  visibility,
+		Audience:    audience,
 	})
+	if err != nil {
+		return code, err
+	}
+
+	// only users who can see the post are told about it
+	event := response.PostEvent{
+		PostID: strconv.Itoa(int(post.ID)),
+		UserID: strconv.Itoa(int(post.UserID)),
+	}
+	switch post.Visibility {
+	case entity.PostVisibilityPublic, entity.PostVisibilityFriends:
+		s.publishToFriends(ctx, post.UserID, entity.EventTypePostCreated, event)
+	case entity.PostVisibilityCustom:
+		for _, id := range post.Audience {
+			s.eventRepo.Publish(id, entity.EventTypePostCreated, event)
+		}
+	}
+	return code, nil
 }
 
 func (s *service) FindAllPost(ctx context.Context, payload request.FindAllPost) ([]response.GetPosts, *common.Meta, int, error) {
-	ent, meta, code, err := s.postRepo.FindAll(ctx, entity.FindAllPostRequest{
-		Limit:  payload.Limit,
-		Offset: payload.Offset,
-		Tags:   payload.Tags,
-		Search: payload.Search,
-		UserID: payload.UserID,
-	})
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+	tags, ok := normalizeTags(payload.Tags)
+	if !ok {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+
+	filter := entity.FindAllPostRequest{
+		Limit:        payload.Limit,
+		Offset:       payload.Offset,
+		Tags:         tags,
+		TagMode:      payload.TagMode,
+		Search:       strings.TrimSpace(payload.Search),
+		UserID:       payload.UserID,
+		CommentLimit: commentPreviewLimit,
+	}
+
+	if payload.AuthorID != "" {
+		authorID, err := strconv.Atoi(payload.AuthorID)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, err.Error())
+		}
+		filter.AuthorID = int64(authorID)
+	}
+	if payload.From != "" {
+		filter.CreatedFrom, err = common.ParseTimeBound(payload.From, false)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, err.Error())
+		}
+	}
+	if payload.To != "" {
+		filter.CreatedTo, err = common.ParseTimeBound(payload.To, true)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, err.Error())
+		}
+	}
+	if filter.CreatedFrom != 0 && filter.CreatedTo != 0 && filter.CreatedFrom >= filter.CreatedTo {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "from must be before to")
+	}
+
+	ent, meta, code, err := s.postRepo.FindAll(ctx, filter)
 
 	if err != nil {
 		return nil, nil, code, err
 	}
 
 	posts := make([]response.GetPosts, len(ent))
-
 	for i, e := range ent {
-		posts[i] = response.GetPosts{
-			PostID: strconv.Itoa(int(e.ID)),
-			Post: struct {
-				PostInHtml string   "json:\"postInHtml\""
-				Tags       []string "json:\"tags\""
-				CreatedAt  string   "json:\"createdAt\""
-			}{
-				PostInHtml: e.ContentHtml,
-				Tags:       strings.Split(e.Tags, ","),
-				CreatedAt:  common.UnixMilliToISO8601(e.CreatedAt),
-			},
-			Creator: struct {
-				UserID      string "json:\"userId\""
-				Name        string "json:\"name\""
-				ImageURL    string "json:\"imageUrl\""
-				FriendCount int    "json:\"friendCount\""
-				CreatedAt   string "json:\"createdAt\""
-			}{
-				UserID:      strconv.Itoa(int(e.Creator.ID)),
-				Name:        e.Creator.Name,
-				ImageURL:    e.Creator.ImageUrl,
-				FriendCount: int(e.Creator.FriendCount),
-				CreatedAt:   common.UnixMilliToISO8601(e.CreatedAt),
-			},
-			Comments: make([]struct {
-				Comment string "json:\"comment\""
-				Creator struct {
-					UserID      string "json:\"userId\""
-					Name        string "json:\"name\""
-					ImageURL    string "json:\"imageUrl\""
-					FriendCount int    "json:\"friendCount\""
-				} "json:\"creator\""
-				CreatedAt string "json:\"createdAt\""
-			}, len(e.Comments)),
-		}
-
-		for j, c := range e.Comments {
-			posts[i].Comments[j] = struct {
-				Comment string "json:\"comment\""
-				Creator struct {
-					UserID      string "json:\"userId\""
-					Name        string "json:\"name\""
-					ImageURL    string "json:\"imageUrl\""
-					FriendCount int    "json:\"friendCount\""
-				} "json:\"creator\""
-				CreatedAt string "json:\"createdAt\""
-			}{
-				Comment: c.Content,
-				Creator: struct {
-					UserID      string "json:\"userId\""
-					Name        string "json:\"name\""
-					ImageURL    string "json:\"imageUrl\""
-					FriendCount int    "json:\"friendCount\""
-				}{
-					UserID:      strconv.Itoa(int(c.Creator.ID)),
-					Name:        c.Creator.Name,
-					ImageURL:    c.Creator.ImageUrl,
-					FriendCount: int(c.Creator.FriendCount),
-				},
-				CreatedAt: common.UnixMilliToISO8601(c.CreatedAt),
-			}
-		}
+		posts[i] = toPostResponse(e)
 	}
 
 	return posts, meta, code, nil
 }
 
+// FindTimeline list posts of the user and their friends using cursor pagination
+func (s *service) FindTimeline(ctx context.Context, payload request.FindTimeline) ([]response.GetPosts, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+	tags, ok := normalizeTags(payload.Tags)
+	if !ok {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+
+	filter := entity.FindTimelineRequest{
+		Limit:        payload.Limit,
+		Tags:         tags,
+		TagMode:      payload.TagMode,
+		Search:       strings.TrimSpace(payload.Search),
+		UserID:       payload.UserID,
+		CommentLimit: commentPreviewLimit,
+	}
+
+	if payload.Cursor != "" {
+		createdAt, id, err := common.DecodeCursor(payload.Cursor)
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, err.Error())
+		}
+		filter.CursorCreatedAt = createdAt
+		filter.CursorID = id
+	}
+
+	ent, code, err := s.postRepo.FindTimeline(ctx, filter)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	posts := make([]response.GetPosts, len(ent))
+	for i, e := range ent {
+		posts[i] = toPostResponse(e)
+	}
+
+	meta := common.Meta{
+		Limit: payload.Limit,
+		Total: len(posts),
+	}
+	if len(ent) > 0 && len(ent) == payload.Limit {
+		last := ent[len(ent)-1]
+		meta.NextCursor = common.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return posts, &meta, code, nil
+}
+
 func (s *service) CreateComment(ctx context.Context, payload request.CreateComment) (int, error) {
 	err := validator.ValidateStruct(&payload)
 	if err != nil {
@@ -124,15 +195,496 @@ func (s *service) CreateComment(ctx context.Context, payload request.CreateComme
 	}
 	// insert comment
 	postID, _ := strconv.Atoi(payload.PostID)
-	_, code, err := s.postRepo.FindByID(ctx, int64(postID))
+	post, code, err := s.postRepo.FindVisibleByID(ctx, int64(postID), payload.UserID)
 
 	if err != nil {
 		return code, err
 	}
 
-	return s.postRepo.CreateComment(ctx, entity.Comment{
+	ent := entity.Comment{
 		Content: payload.Content,
 		PostID:  int64(postID),
 		UserID:  payload.UserID,
+	}
+
+	var parent *entity.Comment
+	if payload.ParentID != "" {
+		parentID, err := strconv.Atoi(payload.ParentID)
+		if err != nil {
+			return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+		}
+
+		parent, code, err = s.postRepo.FindCommentByID(ctx, int64(parentID))
+		if err != nil {
+			return code, err
+		}
+
+		if parent.DeletedAt != nil {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "can not reply to a deleted comment")
+		}
+		if parent.PostID != post.ID {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "parent comment belongs to another post")
+		}
+		if parent.Depth+1 >= maxCommentDepth {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "comment thread is too deep")
+		}
+		ent.ParentID = parent.ID
+		ent.Depth = parent.Depth + 1
+	}
+
+	blockCheck := []int64{post.UserID}
+	if parent != nil {
+		blockCheck = append(blockCheck, parent.UserID)
+	}
+	code, err = s.checkNotBlocked(ctx, payload.UserID, blockCheck...)
+	if err != nil {
+		return code, err
+	}
+
+	// the visibility and friend rules are checked against the post owner for replies too
+	comment, code, err := s.postRepo.CreateComment(ctx, ent)
+	if err != nil {
+		return code, err
+	}
+
+	if post.UserID != payload.UserID {
+		s.eventRepo.Publish(post.UserID, entity.EventTypeCommentCreated, response.CommentEvent{
+			PostID:    strconv.Itoa(int(post.ID)),
+			CommentID: strconv.Itoa(int(comment.ID)),
+			UserID:    strconv.Itoa(int(payload.UserID)),
+		})
+	}
+
+	s.notify(ctx, entity.Notification{
+		UserID:      post.UserID,
+		ActorID:     payload.UserID,
+		Type:        entity.NotificationTypeComment,
+		ReferenceID: post.ID,
 	})
+	if parent != nil && parent.UserID != post.UserID {
+		s.notify(ctx, entity.Notification{
+			UserID:      parent.UserID,
+			ActorID:     payload.UserID,
+			Type:        entity.NotificationTypeReply,
+			ReferenceID: post.ID,
+		})
+	}
+	return code, nil
+}
+
+func (s *service) FindPost(ctx context.Context, payload request.FindPost) (*response.GetPosts, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	postID, err := strconv.Atoi(payload.PostID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	ent, code, err := s.postRepo.FindDetailByID(ctx, int64(postID), payload.UserID, commentPreviewLimit)
+	if err != nil {
+		return nil, code, err
+	}
+
+	post := toPostResponse(*ent)
+	return &post, code, nil
+}
+
+func (s *service) UpdatePost(ctx context.Context, payload request.UpdatePost) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+	tags, ok := normalizeTags(payload.Tags)
+	if !ok {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+
+	postID, err := strconv.Atoi(payload.PostID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	post, code, err := s.postRepo.FindByID(ctx, int64(postID))
+	if err != nil {
+		return code, err
+	}
+
+	if post.UserID != payload.UserID {
+		return http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, errorer.ErrForbidden.Error())
+	}
+
+	contentHtml, contentText, code, err := sanitizePostContent(payload.ContentHtml)
+	if err != nil {
+		return code, err
+	}
+
+	audience, code, err := parsePostAudience(payload.Visibility, payload.Audience, payload.UserID)
+	if err != nil {
+		return code, err
+	}
+
+	return s.postRepo.UpdatePost(ctx, entity.Post{
+		ID:          post.ID,
+		ContentHtml: contentHtml,
+		ContentText: contentText,
+		UserID:      payload.UserID,
+		Tags:        tags,
+		Visibility:  payload.Visibility,
+		Audience:    audience,
+	})
+}
+
+func (s *service) DeletePost(ctx context.Context, payload request.DeletePost) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	postID, err := strconv.Atoi(payload.PostID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	post, code, err := s.postRepo.FindByID(ctx, int64(postID))
+	if err != nil {
+		return code, err
+	}
+
+	if post.UserID != payload.UserID {
+		return http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, errorer.ErrForbidden.Error())
+	}
+
+	return s.postRepo.DeletePost(ctx, post.ID, payload.UserID)
+}
+
+func (s *service) FindAllComments(ctx context.Context, payload request.FindAllComments) ([]response.Comment, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	postID, err := strconv.Atoi(payload.PostID)
+	if err != nil {
+		return nil, nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	_, code, err := s.postRepo.FindVisibleByID(ctx, int64(postID), payload.UserID)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ent, meta, code, err := s.postRepo.FindComments(ctx, entity.FindAllCommentRequest{
+		Limit:    payload.Limit,
+		Offset:   payload.Offset,
+		PostID:   int64(postID),
+		ViewerID: payload.UserID,
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	comments := make([]response.Comment, len(ent))
+	for i, c := range ent {
+		comments[i] = toCommentResponse(c)
+	}
+	return comments, meta, code, nil
+}
+
+// FindAllReplies list the direct replies of a comment, oldest first
+func (s *service) FindAllReplies(ctx context.Context, payload request.FindAllReplies) ([]response.Comment, *common.Meta, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	commentID, err := strconv.Atoi(payload.CommentID)
+	if err != nil {
+		return nil, nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	parent, code, err := s.postRepo.FindCommentByID(ctx, int64(commentID))
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	_, code, err = s.postRepo.FindVisibleByID(ctx, parent.PostID, payload.UserID)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	ent, meta, code, err := s.postRepo.FindComments(ctx, entity.FindAllCommentRequest{
+		Limit:    payload.Limit,
+		Offset:   payload.Offset,
+		PostID:   parent.PostID,
+		ParentID: parent.ID,
+		ViewerID: payload.UserID,
+	})
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	comments := make([]response.Comment, len(ent))
+	for i, c := range ent {
+		comments[i] = toCommentResponse(c)
+	}
+	return comments, meta, code, nil
+}
+
+// UpdateComment edit the content of a comment, only its author is allowed
+func (s *service) UpdateComment(ctx context.Context, payload request.UpdateComment) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	comment, code, err := s.findLiveComment(ctx, payload.CommentID)
+	if err != nil {
+		return code, err
+	}
+
+	if comment.UserID != payload.UserID {
+		return http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, errorer.ErrForbidden.Error())
+	}
+
+	return s.postRepo.UpdateComment(ctx, comment.ID, payload.Content, payload.UserID)
+}
+
+// DeleteComment delete a comment, its author and the owner of the commented post are allowed
+func (s *service) DeleteComment(ctx context.Context, payload request.DeleteComment) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	comment, code, err := s.findLiveComment(ctx, payload.CommentID)
+	if err != nil {
+		return code, err
+	}
+
+	code, err = s.checkCommentModerator(ctx, comment, payload.UserID)
+	if err != nil {
+		return code, err
+	}
+
+	return s.postRepo.DeleteComment(ctx, comment.ID, payload.UserID)
+}
+
+// FindCommentRevisions list the previous versions of a comment to its author and the owner of the commented post
+func (s *service) FindCommentRevisions(ctx context.Context, payload request.FindCommentRevisions) ([]response.CommentRevision, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	commentID, err := strconv.Atoi(payload.CommentID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	comment, code, err := s.postRepo.FindCommentByID(ctx, int64(commentID))
+	if err != nil {
+		return nil, code, err
+	}
+
+	code, err = s.checkCommentModerator(ctx, comment, payload.UserID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	ent, code, err := s.postRepo.FindCommentRevisions(ctx, comment.ID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	revisions := make([]response.CommentRevision, len(ent))
+	for i, e := range ent {
+		revisions[i] = response.CommentRevision{
+			RevisionID: strconv.Itoa(int(e.ID)),
+			Comment:    e.Content,
+			EditedBy:   strconv.Itoa(int(e.EditedBy)),
+			CreatedAt:  common.UnixMilliToISO8601(e.CreatedAt),
+		}
+	}
+	return revisions, code, nil
+}
+
+// findLiveComment load a comment by its string id, deleted comments are reported as not found
+func (s *service) findLiveComment(ctx context.Context, commentID string) (*entity.Comment, int, error) {
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	comment, code, err := s.postRepo.FindCommentByID(ctx, int64(id))
+	if err != nil {
+		return nil, code, err
+	}
+
+	if comment.DeletedAt != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	return comment, http.StatusOK, nil
+}
+
+// checkCommentModerator allow the author of the comment and the owner of the commented post
+func (s *service) checkCommentModerator(ctx context.Context, comment *entity.Comment, userID int64) (int, error) {
+	if comment.UserID == userID {
+		return http.StatusOK, nil
+	}
+
+	post, code, err := s.postRepo.FindByID(ctx, comment.PostID)
+	if err != nil {
+		return code, err
+	}
+
+	if post.UserID != userID {
+		return http.StatusForbidden, errors.Wrap(errorer.ErrForbidden, errorer.ErrForbidden.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// sanitizePostContent return the allowlisted html of a post and its plain text projection
+func sanitizePostContent(contentHtml string) (string, string, int, error) {
+	safeHtml := sanitizer.SanitizeHTML(contentHtml)
+	text := sanitizer.PlainText(safeHtml)
+	if text == "" {
+		return "", "", http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "post content is empty")
+	}
+	return safeHtml, text, http.StatusOK, nil
+}
+
+// normalizeTags trim and lowercase tags and drop duplicates keeping the first occurrence,
+// it returns false when a tag is empty or too long
+func normalizeTags(tags []string) ([]string, bool) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, false
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, true
+}
+
+// parsePostAudience parse the audience user ids of a custom post, dropping duplicates and the author.
+// Other visibilities take no audience.
+func parsePostAudience(visibility string, audience []string, authorID int64) ([]int64, int, error) {
+	if visibility != entity.PostVisibilityCustom {
+		if len(audience) > 0 {
+			return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "audience requires the custom visibility")
+		}
+		return nil, http.StatusOK, nil
+	}
+
+	ids := make([]int64, 0, len(audience))
+	seen := make(map[int64]bool, len(audience))
+	for _, a := range audience {
+		id, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "invalid audience user id")
+		}
+		if int64(id) == authorID || seen[int64(id)] {
+			continue
+		}
+		seen[int64(id)] = true
+		ids = append(ids, int64(id))
+	}
+
+	if len(ids) == 0 {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, "custom visibility requires an audience")
+	}
+	return ids, http.StatusOK, nil
+}
+
+// highlightSnippet escape a plain text search snippet and turn its match markers into <mark> tags
+func highlightSnippet(snippet string) string {
+	if snippet == "" {
+		return ""
+	}
+	return strings.NewReplacer(
+		entity.SnippetMatchStart, "<mark>",
+		entity.SnippetMatchEnd, "</mark>",
+	).Replace(html.EscapeString(snippet))
+}
+
+func toPostResponse(e entity.Post) response.GetPosts {
+	post := response.GetPosts{
+		PostID: strconv.Itoa(int(e.ID)),
+		Post: response.Post{
+			// sanitized again on read so rows stored before sanitization was introduced are safe too
+			PostInHtml: sanitizer.SanitizeHTML(e.ContentHtml),
+			Tags:       e.Tags,
+			Visibility: e.Visibility,
+			CreatedAt:  common.UnixMilliToISO8601(e.CreatedAt),
+		},
+		Creator: response.PostCreator{
+			UserID:      strconv.Itoa(int(e.Creator.ID)),
+			Name:        e.Creator.Name,
+			ImageURL:    e.Creator.ImageUrl,
+			FriendCount: int(e.Creator.FriendCount),
+			CreatedAt:   common.UnixMilliToISO8601(e.Creator.CreatedAt),
+		},
+		Comments:   make([]response.Comment, len(e.Comments)),
+		Reactions:  toReactionsResponse(e.Reactions),
+		Reacted:    e.MyReaction != "",
+		MyReaction: e.MyReaction,
+		Snippet:    highlightSnippet(e.Snippet),
+	}
+
+	for i, c := range e.Comments {
+		post.Comments[i] = toCommentResponse(c)
+	}
+	return post
+}
+
+func toCommentResponse(c entity.Comment) response.Comment {
+	comment := response.Comment{
+		CommentID: strconv.Itoa(int(c.ID)),
+		Comment:   c.Content,
+		Creator: response.CommentCreator{
+			UserID:      strconv.Itoa(int(c.Creator.ID)),
+			Name:        c.Creator.Name,
+			ImageURL:    c.Creator.ImageUrl,
+			FriendCount: int(c.Creator.FriendCount),
+		},
+		ReplyCount: c.ReplyCount,
+		Reactions:  toReactionsResponse(c.Reactions),
+		Reacted:    c.MyReaction != "",
+		MyReaction: c.MyReaction,
+		Edited:     c.EditedAt != nil,
+		CreatedAt:  common.UnixMilliToISO8601(c.CreatedAt),
+	}
+	if c.ParentID != 0 {
+		comment.ParentID = strconv.Itoa(int(c.ParentID))
+	}
+	if c.EditedAt != nil {
+		comment.EditedAt = common.UnixMilliToISO8601(*c.EditedAt)
+	}
+	if c.DeletedAt != nil {
+		return response.Comment{
+			CommentID:  comment.CommentID,
+			ParentID:   comment.ParentID,
+			ReplyCount: comment.ReplyCount,
+			Deleted:    true,
+			CreatedAt:  comment.CreatedAt,
+		}
+	}
+	return comment
+}
+
+func toReactionsResponse(r entity.Reactions) response.Reactions {
+	return response.Reactions{
+		Like:  r.Like,
+		Love:  r.Love,
+		Laugh: r.Laugh,
+	}
 }
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/request"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ReactPost set the reaction of the user on a post, replacing the previous one
+func (s *service) ReactPost(ctx context.Context, payload request.ReactPost) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	postID, err := strconv.Atoi(payload.PostID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	return s.postRepo.ReactPost(ctx, int64(postID), payload.UserID, payload.Type)
+}
+
+func (s *service) UnreactPost(ctx context.Context, payload request.UnreactPost) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	postID, err := strconv.Atoi(payload.PostID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	return s.postRepo.UnreactPost(ctx, int64(postID), payload.UserID)
+}
+
+// ReactComment set the reaction of the user on a comment, replacing the previous one
+func (s *service) ReactComment(ctx context.Context, payload request.ReactComment) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	commentID, err := strconv.Atoi(payload.CommentID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	return s.postRepo.ReactComment(ctx, int64(commentID), payload.UserID, payload.Type)
+}
+
+func (s *service) UnreactComment(ctx context.Context, payload request.UnreactComment) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	commentID, err := strconv.Atoi(payload.CommentID)
+	if err != nil {
+		return http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	return s.postRepo.UnreactComment(ctx, int64(commentID), payload.UserID)
+}
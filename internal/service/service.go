@@ -7,6 +7,7 @@ import (
 	"socialapp/internal/model/request"
 	"socialapp/internal/model/response"
 	"socialapp/internal/repository"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -16,49 +17,173 @@ type Service interface {
 	Register(ctx context.Context, payload request.Register) (*response.Register, int, error)
 	Login(ctx context.Context, payload request.Login) (*response.Login, int, error)
 	GetUserByID(ctx context.Context, id int64) (*response.User, int, error)
+	GetUserProfile(ctx context.Context, payload request.FindUserProfile) (*response.UserProfile, int, error)
 	UpdateAccount(ctx context.Context, payload request.UpdateAccount) (*response.User, int, error)
 	LinkEmail(ctx context.Context, payload request.LinkEmail) (*response.User, int, error)
 	LinkPhone(ctx context.Context, payload request.LinkPhone) (*response.User, int, error)
+	ConfirmCredential(ctx context.Context, payload request.ConfirmCredential) (*response.User, int, error)
+	ResendVerification(ctx context.Context, payload request.ResendVerification) (int, error)
+	// Two-factor
+	LoginTwoFactor(ctx context.Context, payload request.LoginTwoFactor) (*response.Login, int, error)
+	EnrollTwoFactor(ctx context.Context, userID int64) (*response.TwoFactorEnrollment, int, error)
+	ConfirmTwoFactor(ctx context.Context, payload request.ConfirmTwoFactor) (*response.TwoFactorRecoveryCodes, int, error)
+	DisableTwoFactor(ctx context.Context, payload request.DisableTwoFactor) (int, error)
+	// Password
+	ChangePassword(ctx context.Context, payload request.ChangePassword) (int, error)
+	ForgotPassword(ctx context.Context, payload request.ForgotPassword) (int, error)
+	ResetPassword(ctx context.Context, payload request.ResetPassword) (int, error)
+	// Session
+	RefreshToken(ctx context.Context, payload request.RefreshToken) (*response.Token, int, error)
+	ValidateSession(ctx context.Context, sessionID int64, userID int64) (int, error)
+	Logout(ctx context.Context, payload request.Logout) (int, error)
+	LogoutAll(ctx context.Context, userID int64) (int, error)
+	CleanupLoginAttempts(ctx context.Context) (int, error)
+	CleanupSecurityEvents(ctx context.Context) (int, error)
 	// Friendship
 	CreateFriendship(ctx context.Context, payload request.CreateFriendship) (int, error)
 	DeleteFriendship(ctx context.Context, payload request.DeleteFriendship) (int, error)
 	FindAllFriendships(ctx context.Context, filter request.FindAllFriendships) ([]response.FindAllFriendships, *common.Meta, int, error)
-	// s3
-	UploadImage(ctx context.Context, file *multipart.FileHeader) (string, int, error)
+	FindAllFriendRequests(ctx context.Context, filter request.FindAllFriendRequests) ([]response.FriendRequest, *common.Meta, int, error)
+	AcceptFriendRequest(ctx context.Context, payload request.FriendRequestAction) (int, error)
+	RejectFriendRequest(ctx context.Context, payload request.FriendRequestAction) (int, error)
+	CancelFriendRequest(ctx context.Context, payload request.FriendRequestAction) (int, error)
+	FindMutualFriends(ctx context.Context, filter request.FindMutualFriends) ([]response.FindAllFriendships, *common.Meta, int, error)
+	FindFriendSuggestions(ctx context.Context, filter request.FindFriendSuggestions) ([]response.FriendSuggestion, *common.Meta, int, error)
+	// Block
+	BlockUser(ctx context.Context, payload request.BlockUser) (int, error)
+	UnblockUser(ctx context.Context, payload request.UnblockUser) (int, error)
+	FindAllBlocks(ctx context.Context, filter request.FindAllBlocks) ([]response.Block, *common.Meta, int, error)
+	// image
+	UploadImage(ctx context.Context, file *multipart.FileHeader) (*response.Image, int, error)
 	// Post
 	CreatePost(ctx context.Context, payload request.CreatePost) (int, error)
 	CreateComment(ctx context.Context, payload request.CreateComment) (int, error)
 	FindAllPost(ctx context.Context, filter request.FindAllPost) ([]response.GetPosts, *common.Meta, int, error)
+	FindTimeline(ctx context.Context, payload request.FindTimeline) ([]response.GetPosts, *common.Meta, int, error)
+	FindPost(ctx context.Context, payload request.FindPost) (*response.GetPosts, int, error)
+	UpdatePost(ctx context.Context, payload request.UpdatePost) (int, error)
+	DeletePost(ctx context.Context, payload request.DeletePost) (int, error)
+	UpdateComment(ctx context.Context, payload request.UpdateComment) (int, error)
+	DeleteComment(ctx context.Context, payload request.DeleteComment) (int, error)
+	FindCommentRevisions(ctx context.Context, payload request.FindCommentRevisions) ([]response.CommentRevision, int, error)
+	FindAllComments(ctx context.Context, payload request.FindAllComments) ([]response.Comment, *common.Meta, int, error)
+	FindAllReplies(ctx context.Context, payload request.FindAllReplies) ([]response.Comment, *common.Meta, int, error)
+	// Tag
+	FindTrendingTags(ctx context.Context, payload request.FindTrendingTags) ([]response.TrendingTag, int, error)
+	// Reaction
+	ReactPost(ctx context.Context, payload request.ReactPost) (int, error)
+	UnreactPost(ctx context.Context, payload request.UnreactPost) (int, error)
+	ReactComment(ctx context.Context, payload request.ReactComment) (int, error)
+	UnreactComment(ctx context.Context, payload request.UnreactComment) (int, error)
+	// Notification
+	FindAllNotifications(ctx context.Context, filter request.FindAllNotifications) (*response.Notifications, *common.Meta, int, error)
+	ReadNotification(ctx context.Context, payload request.ReadNotification) (int, error)
+	ReadAllNotifications(ctx context.Context, userID int64) (int, error)
+	CleanupNotifications(ctx context.Context) (int, error)
+	// Conversation
+	CreateConversation(ctx context.Context, payload request.CreateConversation) (*response.Conversation, int, error)
+	FindAllConversations(ctx context.Context, filter request.FindAllConversations) ([]response.Conversation, *common.Meta, int, error)
+	SendMessage(ctx context.Context, payload request.SendMessage) (*response.Message, int, error)
+	FindAllMessages(ctx context.Context, payload request.FindAllMessages) ([]response.Message, *common.Meta, int, error)
+	ReadConversation(ctx context.Context, payload request.ReadConversation) (int, error)
+	// Event
+	SubscribeEvents(ctx context.Context, userID int64, lastEventID string) (*EventStream, int, error)
 }
 
 type Config struct {
-	Salt      int
-	JwtSecret string
+	Salt            int
+	JwtSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// MaxImageDimension is the largest width or height in pixels accepted for uploaded images
+	MaxImageDimension int
+	// ImageWorkers is the number of background workers resizing uploaded images,
+	// zero resizes within the upload request
+	ImageWorkers int
+	// NotificationRetention is how long notifications are kept before CleanupNotifications removes them
+	NotificationRetention time.Duration
+	// PasswordResetTTL is how long a password reset token can be used after it is sent
+	PasswordResetTTL time.Duration
+	// VerificationCodeTTL is how long the code sent to a new email or phone can be confirmed
+	VerificationCodeTTL time.Duration
+	// TwoFactorIssuer is the account issuer shown by authenticator apps
+	TwoFactorIssuer string
+	// LoginFailureWindow is how long a failed login counts toward the backoff of its credential and ip
+	LoginFailureWindow time.Duration
+	// LoginLockout is how long a credential or ip is locked after too many failed logins
+	LoginLockout time.Duration
+	// AuditRetention is how long security events are kept before CleanupSecurityEvents removes them
+	AuditRetention time.Duration
 }
 
 type service struct {
-	cfg            Config
-	log            zerolog.Logger
-	userRepo       repository.UserRepository
-	s3Repo         repository.S3Repository
-	postRepo       repository.PostRepository
-	friendshipRepo repository.FriendshipRepository
+	cfg              Config
+	log              zerolog.Logger
+	userRepo         repository.UserRepository
+	blobRepo         repository.BlobRepository
+	postRepo         repository.PostRepository
+	friendshipRepo   repository.FriendshipRepository
+	sessionRepo      repository.SessionRepository
+	notificationRepo repository.NotificationRepository
+	eventRepo        repository.EventRepository
+	conversationRepo repository.ConversationRepository
+	tagRepo          repository.TagRepository
+	blockRepo        repository.BlockRepository
+	resetRepo        repository.PasswordResetRepository
+	messageSender    repository.MessageSender
+	verificationRepo repository.CredentialVerificationRepository
+	twoFactorRepo    repository.TwoFactorRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	auditRepo        repository.SecurityEventRepository
+	imageJobs        chan imageJob
 }
 
 func New(
 	cfg Config,
 	logger zerolog.Logger,
 	userRepo repository.UserRepository,
-	s3Repo repository.S3Repository,
+	blobRepo repository.BlobRepository,
 	postRepo repository.PostRepository,
 	friendshipRepo repository.FriendshipRepository,
+	sessionRepo repository.SessionRepository,
+	notificationRepo repository.NotificationRepository,
+	eventRepo repository.EventRepository,
+	conversationRepo repository.ConversationRepository,
+	tagRepo repository.TagRepository,
+	blockRepo repository.BlockRepository,
+	resetRepo repository.PasswordResetRepository,
+	messageSender repository.MessageSender,
+	verificationRepo repository.CredentialVerificationRepository,
+	twoFactorRepo repository.TwoFactorRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	auditRepo repository.SecurityEventRepository,
 ) Service {
-	return &service{
-		cfg:            cfg,
-		log:            logger,
-		userRepo:       userRepo,
-		s3Repo:         s3Repo,
-		postRepo:       postRepo,
-		friendshipRepo: friendshipRepo,
+	s := &service{
+		cfg:              cfg,
+		log:              logger,
+		userRepo:         userRepo,
+		blobRepo:         blobRepo,
+		postRepo:         postRepo,
+		friendshipRepo:   friendshipRepo,
+		sessionRepo:      sessionRepo,
+		notificationRepo: notificationRepo,
+		eventRepo:        eventRepo,
+		conversationRepo: conversationRepo,
+		tagRepo:          tagRepo,
+		blockRepo:        blockRepo,
+		resetRepo:        resetRepo,
+		messageSender:    messageSender,
+		verificationRepo: verificationRepo,
+		twoFactorRepo:    twoFactorRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		auditRepo:        auditRepo,
 	}
+
+	if cfg.ImageWorkers > 0 {
+		s.imageJobs = make(chan imageJob, cfg.ImageWorkers*16)
+		for i := 0; i < cfg.ImageWorkers; i++ {
+			go s.imageWorker()
+		}
+	}
+	return s
 }
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/jwt"
+	"socialapp/internal/helper/token"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"time"
+
+	jwtV5 "github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// issueTokens start a new session for the user and return its access and refresh token
+func (s *service) issueTokens(ctx context.Context, userID int64) (*response.Token, int, error) {
+	refreshToken, err := token.Generate(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	session, code, err := s.sessionRepo.Create(ctx, entity.Session{
+		UserID:           userID,
+		RefreshTokenHash: token.Hash(refreshToken),
+		ExpiresAt:        time.Now().Add(s.cfg.RefreshTokenTTL).UnixMilli(),
+	})
+	if err != nil {
+		return nil, code, err
+	}
+
+	accessToken, err := s.generateAccessToken(userID, session.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, err.Error())
+	}
+
+	return &response.Token{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, http.StatusOK, nil
+}
+
+func (s *service) generateAccessToken(userID int64, sessionID int64) (string, error) {
+	userClaims := common.UserClaims{
+		Id:        userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwtV5.RegisteredClaims{
+			IssuedAt:  jwtV5.NewNumericDate(time.Now()),
+			ExpiresAt: jwtV5.NewNumericDate(time.Now().Add(s.cfg.AccessTokenTTL)),
+		},
+	}
+	return jwt.GenerateJwt(userClaims, s.cfg.JwtSecret)
+}
+
+// RefreshToken exchange a refresh token for a new access token, the refresh token is rotated on every use
+func (s *service) RefreshToken(ctx context.Context, payload request.RefreshToken) (*response.Token, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	oldHash := token.Hash(payload.RefreshToken)
+	session, code, err := s.sessionRepo.FindByRefreshToken(ctx, oldHash)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return nil, http.StatusUnauthorized, errors.Wrap(errorer.ErrUnauthorized, errorer.ErrUnauthorized.Error())
+		}
+		return nil, code, err
+	}
+
+	if session.RevokedAt != nil || session.ExpiresAt < time.Now().UnixMilli() {
+		return nil, http.StatusUnauthorized, errors.Wrap(errorer.ErrUnauthorized, errorer.ErrUnauthorized.Error())
+	}
+
+	refreshToken, err := token.Generate(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	code, err = s.sessionRepo.Rotate(ctx, session.ID, oldHash, token.Hash(refreshToken), time.Now().Add(s.cfg.RefreshTokenTTL).UnixMilli())
+	if err != nil {
+		return nil, code, err
+	}
+
+	accessToken, err := s.generateAccessToken(session.UserID, session.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, err.Error())
+	}
+
+	return &response.Token{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, http.StatusOK, nil
+}
+
+// ValidateSession check the session of an access token is still active and not expired
+func (s *service) ValidateSession(ctx context.Context, sessionID int64, userID int64) (int, error) {
+	if sessionID == 0 {
+		return http.StatusUnauthorized, errors.Wrap(errorer.ErrUnauthorized, errorer.ErrUnauthorized.Error())
+	}
+
+	session, code, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return http.StatusUnauthorized, errors.Wrap(errorer.ErrUnauthorized, errorer.ErrUnauthorized.Error())
+		}
+		return code, err
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil || session.ExpiresAt <= time.Now().UnixMilli() {
+		return http.StatusUnauthorized, errors.Wrap(errorer.ErrUnauthorized, errorer.ErrUnauthorized.Error())
+	}
+	return http.StatusOK, nil
+}
+
+func (s *service) Logout(ctx context.Context, payload request.Logout) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	return s.sessionRepo.Revoke(ctx, payload.SessionID, payload.UserID)
+}
+
+func (s *service) LogoutAll(ctx context.Context, userID int64) (int, error) {
+	return s.sessionRepo.RevokeAllByUserID(ctx, userID)
+}
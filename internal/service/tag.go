@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FindTrendingTags rank tags by how many posts used them in the last payload.Days days
+func (s *service) FindTrendingTags(ctx context.Context, payload request.FindTrendingTags) ([]response.TrendingTag, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	since := time.Now().AddDate(0, 0, -payload.Days).UnixMilli()
+	ent, code, err := s.tagRepo.FindTrending(ctx, since, payload.Limit)
+	if err != nil {
+		return nil, code, err
+	}
+
+	tags := make([]response.TrendingTag, len(ent))
+	for i, e := range ent {
+		tags[i] = response.TrendingTag{
+			Tag:       e.Name,
+			PostCount: e.PostCount,
+			LastUsed:  common.UnixMilliToISO8601(e.LastUsedAt),
+		}
+	}
+	return tags, code, nil
+}
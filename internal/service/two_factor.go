@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/token"
+	"socialapp/internal/helper/totp"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// totpSkew is the number of periods before and after now a code is accepted, for clock drift
+	totpSkew          = 1
+	recoveryCodeCount = 10
+	// recoveryCodeBytes give each recovery code 80 bits so their unsalted hashes cannot be brute forced
+	recoveryCodeBytes = 10
+	loginChallengeTTL = 5 * time.Minute
+	// maxLoginChallengeAttempts is the number of codes tried on a login challenge before it stops working
+	maxLoginChallengeAttempts = 5
+)
+
+// twoFactorChallenge return the token of a new login challenge when the user enabled
+// two-factor authentication, and an empty token otherwise
+func (s *service) twoFactorChallenge(ctx context.Context, userID int64) (string, int, error) {
+	tf, code, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return "", http.StatusOK, nil
+		}
+		return "", code, err
+	}
+	if tf.EnabledAt == nil {
+		return "", http.StatusOK, nil
+	}
+
+	challenge, err := token.Generate(32)
+	if err != nil {
+		return "", http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	_, code, err = s.twoFactorRepo.CreateChallenge(ctx, entity.LoginChallenge{
+		UserID:    userID,
+		TokenHash: token.Hash(challenge),
+		ExpiresAt: time.Now().Add(loginChallengeTTL).UnixMilli(),
+	})
+	if err != nil {
+		return "", code, err
+	}
+	return challenge, http.StatusOK, nil
+}
+
+// normalizeRecoveryCode drop the separators and case users may type in a recovery code
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// formatRecoveryCode split a recovery code in groups of five characters so it is easier to copy
+func formatRecoveryCode(raw string) string {
+	groups := make([]string, 0, len(raw)/5+1)
+	for len(raw) > 5 {
+		groups = append(groups, raw[:5])
+		raw = raw[5:]
+	}
+	return strings.Join(append(groups, raw), "-")
+}
+
+// verifySecondFactor accept a TOTP code or an unused recovery code of an enabled setup,
+// each code is accepted only once
+func (s *service) verifySecondFactor(ctx context.Context, tf *entity.TwoFactor, code string) (int, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits && strings.Trim(code, "0123456789") == "" {
+		step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidCode, errorer.ErrInvalidCode.Error())
+		}
+		return s.twoFactorRepo.UseStep(ctx, tf.UserID, step)
+	}
+
+	return s.twoFactorRepo.UseRecoveryCode(ctx, tf.UserID, token.Hash(normalizeRecoveryCode(code)))
+}
+
+// LoginTwoFactor complete a login started by Login with a TOTP or recovery code
+func (s *service) LoginTwoFactor(ctx context.Context, payload request.LoginTwoFactor) (*response.Login, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	tokenHash := token.Hash(payload.Token)
+	challenge, code, err := s.twoFactorRepo.FindChallenge(ctx, tokenHash, maxLoginChallengeAttempts)
+	if err != nil {
+		return nil, code, err
+	}
+
+	tf, code, err := s.twoFactorRepo.FindByUserID(ctx, challenge.UserID)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return nil, http.StatusUnauthorized, errors.Wrap(errorer.ErrInvalidToken, errorer.ErrInvalidToken.Error())
+		}
+		return nil, code, err
+	}
+	if tf.EnabledAt == nil {
+		return nil, http.StatusUnauthorized, errors.Wrap(errorer.ErrInvalidToken, errorer.ErrInvalidToken.Error())
+	}
+
+	keys := twoFactorKeys(challenge.UserID, payload.IP)
+	event := entity.SecurityEvent{
+		UserID:    challenge.UserID,
+		EventType: entity.SecurityEventTwoFactorFailed,
+		IPAddress: payload.IP,
+	}
+	attempts, code, err := s.reserveLogin(ctx, event, keys)
+	if err != nil {
+		return nil, code, err
+	}
+
+	// the challenge attempt is only used once the throttle let the request through
+	_, code, err = s.twoFactorRepo.AttemptChallenge(ctx, tokenHash, maxLoginChallengeAttempts)
+	if err != nil {
+		s.releaseLogins(ctx, keys)
+		return nil, code, err
+	}
+
+	code, err = s.verifySecondFactor(ctx, tf, payload.Code)
+	if err != nil {
+		if code == http.StatusBadRequest {
+			s.recordLoginFailure(ctx, event, keys, attempts)
+		} else {
+			s.releaseLogins(ctx, keys)
+		}
+		return nil, code, err
+	}
+	s.resetLoginFailures(ctx, keys)
+
+	code, err = s.twoFactorRepo.DeleteChallenge(ctx, challenge.ID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	user, code, err := s.userRepo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	tkn, code, err := s.issueTokens(ctx, user.ID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &response.Login{
+		Name:         user.Name,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		AccessToken:  tkn.AccessToken,
+		RefreshToken: tkn.RefreshToken,
+	}, http.StatusOK, nil
+}
+
+// EnrollTwoFactor generate a new TOTP secret for the user, it protects logins once ConfirmTwoFactor
+// receive a first valid code
+func (s *service) EnrollTwoFactor(ctx context.Context, userID int64) (*response.TwoFactorEnrollment, int, error) {
+	user, code, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	code, err = s.twoFactorRepo.SavePending(ctx, userID, secret)
+	if err != nil {
+		return nil, code, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	if account == "" {
+		account = user.Name
+	}
+
+	return &response.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.TwoFactorIssuer, account, secret),
+	}, http.StatusOK, nil
+}
+
+// ConfirmTwoFactor enable the enrollment of the user with a first code and return new recovery codes
+func (s *service) ConfirmTwoFactor(ctx context.Context, payload request.ConfirmTwoFactor) (*response.TwoFactorRecoveryCodes, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	tf, code, err := s.twoFactorRepo.FindByUserID(ctx, payload.UserID)
+	if err != nil {
+		return nil, code, err
+	}
+	if tf.EnabledAt != nil {
+		return nil, http.StatusConflict, errors.Wrap(errorer.ErrTwoFactorEnabled, errorer.ErrTwoFactorEnabled.Error())
+	}
+
+	step, ok := totp.Validate(tf.Secret, payload.Code, time.Now(), totpSkew)
+	if !ok {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidCode, errorer.ErrInvalidCode.Error())
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := token.Generate(recoveryCodeBytes)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+		}
+		codes[i] = formatRecoveryCode(raw)
+		hashes[i] = token.Hash(raw)
+	}
+
+	code, err = s.twoFactorRepo.Enable(ctx, payload.UserID, step, hashes)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &response.TwoFactorRecoveryCodes{
+		RecoveryCodes: codes,
+	}, http.StatusOK, nil
+}
+
+// DisableTwoFactor remove two-factor authentication after checking the password and a code of the user
+func (s *service) DisableTwoFactor(ctx context.Context, payload request.DisableTwoFactor) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	// a stolen session must not be able to guess the password and code without limit
+	keys := twoFactorKeys(payload.UserID, payload.IP)
+	event := entity.SecurityEvent{
+		UserID:    payload.UserID,
+		EventType: entity.SecurityEventTwoFactorFailed,
+		IPAddress: payload.IP,
+	}
+	attempts, code, err := s.reserveLogin(ctx, event, keys)
+	if err != nil {
+		return code, err
+	}
+
+	user, code, err := s.userRepo.FindByID(ctx, payload.UserID)
+	if err != nil {
+		s.releaseLogins(ctx, keys)
+		return code, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password))
+	if err != nil {
+		s.recordLoginFailure(ctx, event, keys, attempts)
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrWrongPassword, errorer.ErrWrongPassword.Error())
+	}
+
+	tf, code, err := s.twoFactorRepo.FindByUserID(ctx, payload.UserID)
+	if err == nil && tf.EnabledAt == nil {
+		code, err = http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+	if err != nil {
+		s.releaseLogins(ctx, keys)
+		return code, err
+	}
+
+	code, err = s.verifySecondFactor(ctx, tf, payload.Code)
+	if err != nil {
+		if code == http.StatusBadRequest {
+			s.recordLoginFailure(ctx, event, keys, attempts)
+		} else {
+			s.releaseLogins(ctx, keys)
+		}
+		return code, err
+	}
+	s.resetLoginFailures(ctx, keys)
+
+	return s.twoFactorRepo.Delete(ctx, payload.UserID)
+}
@@ -6,20 +6,18 @@ import (
 	"regexp"
 	"socialapp/internal/helper/common"
 	"socialapp/internal/helper/errorer"
-	"socialapp/internal/helper/jwt"
 	"socialapp/internal/helper/validator"
 	"socialapp/internal/model/entity"
 	"socialapp/internal/model/request"
 	"socialapp/internal/model/response"
-	"time"
-
-	jwtV5 "github.com/golang-jwt/jwt/v5"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Register to register a new user by email and password
+// Register to register a new user by email and password, the email or phone stays pending
+// until the user confirms the code sent to it
 func (s *service) Register(ctx context.Context, payload request.Register) (*response.Register, int, error) {
 	err := validator.ValidateStruct(&payload)
 
@@ -49,8 +47,6 @@ func (s *service) Register(ctx context.Context, payload request.Register) (*resp
 		if exist != nil {
 			return nil, http.StatusConflict, errors.Wrap(errorer.ErrEmailExist, errorer.ErrEmailExist.Error())
 		}
-
-		ent.Email = payload.CredentialValue
 	}
 
 	if payload.CredentialType == "phone" {
@@ -71,7 +67,14 @@ func (s *service) Register(ctx context.Context, payload request.Register) (*resp
 		if exist != nil {
 			return nil, http.StatusConflict, errors.Wrap(errorer.ErrPhoneExist, errorer.ErrPhoneExist.Error())
 		}
-		ent.Phone = payload.CredentialValue
+	}
+
+	pending, code, err := s.verificationRepo.FindPending(ctx, payload.CredentialType, payload.CredentialValue)
+	if err != nil && code != http.StatusNotFound {
+		return nil, code, err
+	}
+	if pending != nil {
+		return nil, http.StatusConflict, errors.Wrap(errorer.ErrPendingExist, errorer.ErrPendingExist.Error())
 	}
 
 	// Hash the password before storing it
@@ -81,31 +84,27 @@ func (s *service) Register(ctx context.Context, payload request.Register) (*resp
 	}
 	ent.Password = string(hashedPassword)
 
-	user, code, err := s.userRepo.Register(ctx, ent)
+	verification, verifyCode, err := s.newVerification(0, payload.CredentialType, payload.CredentialValue)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// the user and its pending credential are saved together
+	user, code, err := s.verificationRepo.Register(ctx, ent, verification)
 
 	if err != nil {
 		return nil, code, err
 	}
 
-	// TODO: generate access token
-	userClaims := common.UserClaims{
-		Id: user.ID,
-		RegisteredClaims: jwtV5.RegisteredClaims{
-			IssuedAt:  jwtV5.NewNumericDate(time.Now()),
-			ExpiresAt: jwtV5.NewNumericDate(time.Now().Add(time.Hour * 24 * 30)),
-		},
-	}
-	tokenString, err := jwt.GenerateJwt(userClaims, s.cfg.JwtSecret)
+	sendCode, err := s.sendVerificationCode(ctx, payload.CredentialType, payload.CredentialValue, verifyCode)
 
 	if err != nil {
-		return nil, code, errors.Wrap(err, err.Error())
+		return nil, sendCode, err
 	}
 
 	return &response.Register{
-		Name:        user.Name,
-		Email:       user.Email,
-		Phone:       user.Phone,
-		AccessToken: tokenString,
+		Name:                 user.Name,
+		VerificationRequired: true,
 	}, code, nil
 }
 
@@ -120,21 +119,12 @@ func (s *service) Login(ctx context.Context, payload request.Login) (*response.L
 		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
 	}
 
-	user := &entity.User{}
-
 	if payload.CredentialType == "email" {
 		// validate email form
 		regex := regexp.MustCompile(common.RegexEmailPattern)
 		if !regex.MatchString(payload.CredentialValue) {
 			return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidEmail, errorer.ErrInvalidEmail.Error())
 		}
-
-		usr, code, err := s.userRepo.FindByEmail(ctx, payload.CredentialValue)
-
-		if err != nil {
-			return nil, code, err
-		}
-		user = usr
 	}
 
 	if payload.CredentialType == "phone" {
@@ -146,10 +136,31 @@ func (s *service) Login(ctx context.Context, payload request.Login) (*response.L
 		if !common.ValidatePhoneNumber(payload.CredentialValue) {
 			return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidPhone, errorer.ErrInvalidPhone.Error())
 		}
+	}
+
+	keys := loginKeys(payload.CredentialType, payload.CredentialValue, payload.IP)
+	attempts, code, err := s.reserveLogin(ctx, loginEvent(payload, 0), keys)
+	if err != nil {
+		return nil, code, err
+	}
 
+	user := &entity.User{}
+
+	if payload.CredentialType == "email" {
+		usr, code, err := s.userRepo.FindByEmail(ctx, payload.CredentialValue)
+
+		if err != nil {
+			code, err = s.loginNotFound(ctx, payload, keys, attempts, code, err)
+			return nil, code, err
+		}
+		user = usr
+	}
+
+	if payload.CredentialType == "phone" {
 		usr, code, err := s.userRepo.FindByPhone(ctx, payload.CredentialValue)
 
 		if err != nil {
+			code, err = s.loginNotFound(ctx, payload, keys, attempts, code, err)
 			return nil, code, err
 		}
 		user = usr
@@ -158,30 +169,68 @@ func (s *service) Login(ctx context.Context, payload request.Login) (*response.L
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password))
 	if err != nil {
+		s.recordLoginFailure(ctx, loginEvent(payload, user.ID), keys, attempts)
 		return nil, http.StatusBadRequest, errors.Wrap(err, err.Error())
 	}
+	s.resetLoginFailures(ctx, keys)
 
-	userClaims := common.UserClaims{
-		Id: user.ID,
-		RegisteredClaims: jwtV5.RegisteredClaims{
-			IssuedAt:  jwtV5.NewNumericDate(time.Now()),
-			ExpiresAt: jwtV5.NewNumericDate(time.Now().Add(time.Hour * 24 * 30)),
-		},
+	challenge, code, err := s.twoFactorChallenge(ctx, user.ID)
+	if err != nil {
+		return nil, code, err
+	}
+	if challenge != "" {
+		return &response.Login{
+			Name:              user.Name,
+			Email:             user.Email,
+			Phone:             user.Phone,
+			TwoFactorRequired: true,
+			TwoFactorToken:    challenge,
+		}, http.StatusOK, nil
 	}
-	tokenString, err := jwt.GenerateJwt(userClaims, s.cfg.JwtSecret)
+
+	tkn, code, err := s.issueTokens(ctx, user.ID)
 
 	if err != nil {
-		return nil, http.StatusInternalServerError, errors.Wrap(err, err.Error())
+		return nil, code, err
 	}
 
 	return &response.Login{
-		Name:        user.Name,
-		Email:       user.Email,
-		Phone:       user.Phone,
-		AccessToken: tokenString,
+		Name:         user.Name,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		AccessToken:  tkn.AccessToken,
+		RefreshToken: tkn.RefreshToken,
 	}, http.StatusOK, nil
 }
 
+// loginNotFound refuse the login of a credential still pending verification with a clearer
+// error than not found, and audit the failed login of an unknown credential
+func (s *service) loginNotFound(ctx context.Context, payload request.Login, keys []throttleKey, attempts []*entity.LoginAttempt, code int, err error) (int, error) {
+	if code != http.StatusNotFound {
+		s.releaseLogins(ctx, keys)
+		return code, err
+	}
+
+	pending, _, _ := s.verificationRepo.FindPending(ctx, payload.CredentialType, payload.CredentialValue)
+	if pending != nil {
+		s.releaseLogins(ctx, keys)
+		return http.StatusForbidden, errors.Wrap(errorer.ErrNotVerified, errorer.ErrNotVerified.Error())
+	}
+
+	s.recordLoginFailure(ctx, loginEvent(payload, 0), keys, attempts)
+	return code, err
+}
+
+func loginEvent(payload request.Login, userID int64) entity.SecurityEvent {
+	return entity.SecurityEvent{
+		UserID:          userID,
+		EventType:       entity.SecurityEventLoginFailed,
+		CredentialType:  payload.CredentialType,
+		CredentialValue: payload.CredentialValue,
+		IPAddress:       payload.IP,
+	}
+}
+
 func (s *service) GetUserByID(ctx context.Context, id int64) (*response.User, int, error) {
 	user, code, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
@@ -199,6 +248,42 @@ func (s *service) GetUserByID(ctx context.Context, id int64) (*response.User, in
 	}, code, nil
 }
 
+// GetUserProfile return the profile of a user as seen by the viewer, private fields are
+// only included when viewers look at their own profile
+func (s *service) GetUserProfile(ctx context.Context, payload request.FindUserProfile) (*response.UserProfile, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	userID, err := strconv.Atoi(payload.UserID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.Wrap(errorer.ErrNotFound, errorer.ErrNotFound.Error())
+	}
+
+	profile, code, err := s.userRepo.FindProfile(ctx, int64(userID), payload.ViewerID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	ret := &response.UserProfile{
+		UserID:            strconv.Itoa(int(profile.ID)),
+		Name:              profile.Name,
+		ImageURL:          profile.ImageUrl,
+		FriendCount:       profile.FriendCount,
+		PostCount:         profile.PostCount,
+		IsFriend:          profile.IsFriend,
+		MutualFriendCount: profile.MutualFriendCount,
+		CreatedAt:         common.UnixMilliToISO8601(profile.CreatedAt),
+	}
+	if profile.ID == payload.ViewerID {
+		ret.Email = profile.Email
+		ret.Phone = profile.Phone
+		ret.MutualFriendCount = 0
+	}
+	return ret, code, nil
+}
+
 func (s *service) UpdateAccount(ctx context.Context, payload request.UpdateAccount) (*response.User, int, error) {
 	err := validator.ValidateStruct(&payload)
 	if err != nil {
@@ -265,8 +350,8 @@ func (s *service) LinkPhone(ctx context.Context, payload request.LinkPhone) (*re
 		return nil, http.StatusConflict, errors.Wrap(errorer.ErrPhoneExist, errorer.ErrPhoneExist.Error())
 	}
 
-	ent.Phone = payload.Phone
-	ent, code, err = s.userRepo.UpdateByID(ctx, *ent)
+	// the phone is set on the user by ConfirmCredential
+	code, err = s.startVerification(ctx, ent.ID, entity.CredentialTypePhone, payload.Phone)
 	if err != nil {
 		return nil, code, err
 	}
@@ -280,7 +365,7 @@ func (s *service) LinkPhone(ctx context.Context, payload request.LinkPhone) (*re
 		FriendCount: ent.FriendCount,
 		CreatedAt:   ent.CreatedAt,
 		UpdatedAt:   ent.UpdatedAt,
-	}, code, nil
+	}, http.StatusAccepted, nil
 }
 
 func (s *service) LinkEmail(ctx context.Context, payload request.LinkEmail) (*response.User, int, error) {
@@ -308,8 +393,8 @@ func (s *service) LinkEmail(ctx context.Context, payload request.LinkEmail) (*re
 		return nil, http.StatusConflict, errors.Wrap(errorer.ErrEmailExist, errorer.ErrEmailExist.Error())
 	}
 
-	ent.Email = payload.Email
-	ent, code, err = s.userRepo.UpdateByID(ctx, *ent)
+	// the email is set on the user by ConfirmCredential
+	code, err = s.startVerification(ctx, ent.ID, entity.CredentialTypeEmail, payload.Email)
 	if err != nil {
 		return nil, code, err
 	}
@@ -323,5 +408,5 @@ func (s *service) LinkEmail(ctx context.Context, payload request.LinkEmail) (*re
 		FriendCount: ent.FriendCount,
 		CreatedAt:   ent.CreatedAt,
 		UpdatedAt:   ent.UpdatedAt,
-	}, code, nil
+	}, http.StatusAccepted, nil
 }
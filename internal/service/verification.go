@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"socialapp/internal/helper/common"
+	"socialapp/internal/helper/errorer"
+	"socialapp/internal/helper/token"
+	"socialapp/internal/helper/validator"
+	"socialapp/internal/model/entity"
+	"socialapp/internal/model/request"
+	"socialapp/internal/model/response"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	verificationCodeDigits = 6
+	// maxVerificationAttempts is the number of wrong codes accepted before a code stops working
+	maxVerificationAttempts = 5
+	// maxVerificationSends is the number of codes sent for one pending credential, resends included
+	maxVerificationSends       = 5
+	verificationResendCooldown = time.Minute
+)
+
+// validateCredential check the form of an email address or phone number
+func validateCredential(credentialType string, credentialValue string) (int, error) {
+	switch credentialType {
+	case entity.CredentialTypeEmail:
+		regex := regexp.MustCompile(common.RegexEmailPattern)
+		if !regex.MatchString(credentialValue) {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidEmail, errorer.ErrInvalidEmail.Error())
+		}
+	case entity.CredentialTypePhone:
+		if pl := len(credentialValue); pl < 7 || pl > 13 || !common.ValidatePhoneNumber(credentialValue) {
+			return http.StatusBadRequest, errors.Wrap(errorer.ErrInvalidPhone, errorer.ErrInvalidPhone.Error())
+		}
+	default:
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrBadRequest, errorer.ErrBadRequest.Error())
+	}
+	return http.StatusOK, nil
+}
+
+// newVerification return the verification of a credential and the code to send to it
+func (s *service) newVerification(userID int64, credentialType string, credentialValue string) (entity.CredentialVerification, string, error) {
+	code, err := token.GenerateCode(verificationCodeDigits)
+	if err != nil {
+		return entity.CredentialVerification{}, "", errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	return entity.CredentialVerification{
+		UserID:          userID,
+		CredentialType:  credentialType,
+		CredentialValue: credentialValue,
+		CodeHash:        token.Hash(code),
+		ExpiresAt:       time.Now().Add(s.cfg.VerificationCodeTTL).UnixMilli(),
+	}, code, nil
+}
+
+// startVerification save the credential as pending for the user and send it a code
+func (s *service) startVerification(ctx context.Context, userID int64, credentialType string, credentialValue string) (int, error) {
+	verification, code, err := s.newVerification(userID, credentialType, credentialValue)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	_, httpCode, err := s.verificationRepo.Create(ctx, verification)
+	if err != nil {
+		return httpCode, err
+	}
+
+	return s.sendVerificationCode(ctx, credentialType, credentialValue, code)
+}
+
+func (s *service) sendVerificationCode(ctx context.Context, credentialType string, credentialValue string, code string) (int, error) {
+	subject := "Verify your email"
+	if credentialType == entity.CredentialTypePhone {
+		subject = "Verify your phone number"
+	}
+
+	httpCode, err := s.messageSender.Send(ctx, entity.OutgoingMessage{
+		Channel: credentialType,
+		To:      credentialValue,
+		Subject: subject,
+		Body:    fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.cfg.VerificationCodeTTL),
+	})
+	if err != nil {
+		return httpCode, err
+	}
+	return http.StatusOK, nil
+}
+
+// ConfirmCredential check the code sent to a pending credential and make it the credential of its user
+func (s *service) ConfirmCredential(ctx context.Context, payload request.ConfirmCredential) (*response.User, int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	userID, code, err := s.verificationRepo.Confirm(ctx, payload.CredentialType, payload.CredentialValue, token.Hash(payload.Code), maxVerificationAttempts)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return s.GetUserByID(ctx, userID)
+}
+
+// ResendVerification send a new code to a pending credential. It succeed when nothing is pending
+// so the endpoint cannot be used to find pending credentials.
+func (s *service) ResendVerification(ctx context.Context, payload request.ResendVerification) (int, error) {
+	err := validator.ValidateStruct(&payload)
+	if err != nil {
+		return http.StatusBadRequest, errors.Wrap(errorer.ErrInputRequest(err), errorer.ErrInputRequest(err).Error())
+	}
+
+	verification, code, err := s.verificationRepo.FindPending(ctx, payload.CredentialType, payload.CredentialValue)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return http.StatusOK, nil
+		}
+		return code, err
+	}
+
+	newCode, err := token.GenerateCode(verificationCodeDigits)
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(errorer.ErrInternalServer, err.Error())
+	}
+
+	now := time.Now()
+	code, err = s.verificationRepo.Renew(ctx, verification.ID, token.Hash(newCode),
+		now.Add(s.cfg.VerificationCodeTTL).UnixMilli(), now.Add(-verificationResendCooldown).UnixMilli(), maxVerificationSends)
+	if err != nil {
+		return code, err
+	}
+
+	return s.sendVerificationCode(ctx, payload.CredentialType, payload.CredentialValue, newCode)
+}